@@ -1,9 +1,14 @@
 package component
 
 import (
+	"encoding/json"
+
+	"github.com/Tomislaw/far-worlds/ecs"
 	"github.com/google/uuid"
 )
 
+// GUID is persistent identity of entity, unlike entity id it's kept when entity is saved,
+// restored or transferred to another map. GUIDs are unique within manager.
 type GUID struct {
 	guid string
 }
@@ -15,5 +20,30 @@ func NewRandomGUID() GUID {
 
 // NewGUID cretes new GUID component with provided id value
 func NewGUID(guid string) GUID {
-	return GUID{}
+	return GUID{guid}
+}
+
+func (g GUID) String() string {
+	return g.guid
+}
+
+// FindByGUID returns entity of manager with GUID
+func FindByGUID(manager *ecs.Manager, guid string) (*ecs.Entity, bool) {
+	return manager.Lookup(Type.GUID, guid)
+}
+
+// NewGUIDIndex creates index of entities by GUID, index shared by managers keeps GUIDs
+// unique in all of them
+func NewGUIDIndex() *ecs.Index {
+	return ecs.NewIndex(func(component interface{}) string {
+		return component.(GUID).guid
+	})
+}
+
+func (g GUID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.guid)
+}
+
+func (g *GUID) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &g.guid)
 }
@@ -1,25 +1,51 @@
 package component
 
-type MapItem struct {
-	tileX uint8
-	tileY uint8
-	tileZ uint8
-
-	chunkX uint8
-	chunkY uint8
+import "github.com/Tomislaw/far-worlds/world/coord"
 
-	mapId uint8
+type MapItem struct {
+	Position coord.Position
+	MapID    uint8
 }
 
+// MapItemBlock makes entity occupy box of tiles starting at its MapItem position,
+// so other blocks can't be placed or moved there
 type MapItemBlock struct {
-	sizeX uint8
-	sizeY uint8
-	sizeZ uint8
+	SizeX uint8
+	SizeY uint8
+	SizeZ uint8
+}
+
+// Size returns size of footprint, zero sizes are treated as single tile
+func (b MapItemBlock) Size() coord.Size {
+	size := coord.Size{X: int(b.SizeX), Y: int(b.SizeY), Z: int(b.SizeZ)}
+	if size.X == 0 {
+		size.X = 1
+	}
+	if size.Y == 0 {
+		size.Y = 1
+	}
+	if size.Z == 0 {
+		size.Z = 1
+	}
+	return size
 }
 
+// MapItemMovement moves entity tile by tile towards target, removed when target is reached
 type MapItemMovement struct {
-	moveX    uint8
-	moveY    uint8
-	moveZ    uint8
-	progress float32
+	Target coord.Position
+	// Speed in tiles per second, defaults to 1
+	Speed    float32
+	Progress float32
+}
+
+// ChunkLoader keeps chunks in radius around entity loaded, used by players and active entities
+type ChunkLoader struct {
+	Radius int
+}
+
+// Attached makes MapItem of entity follow MapItem of its parent, e.g. item carried by
+// character or rider on mount. Attached entities don't occupy tiles.
+type Attached struct {
+	// Offset from position of parent
+	Offset coord.Position
 }
@@ -0,0 +1,22 @@
+package component
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Tomislaw/far-worlds/ecs"
+)
+
+// LoadPrefabs loads entity templates from json file, templates are validated against Registry
+func LoadPrefabs(path string) (*ecs.Prefabs, error) {
+	fmt.Println("Loading prefabs: " + path)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prefabs: %v", err)
+	}
+	prefabs, err := ecs.LoadPrefabs(data, Registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prefabs %v: %v", path, err)
+	}
+	return prefabs, nil
+}
@@ -11,6 +11,13 @@ type TypeDefinitions struct {
 	MapItem         reflect.Type
 	MapItemBlock    reflect.Type
 	MapItemMovement reflect.Type
+	ChunkLoader     reflect.Type
+	Attached        reflect.Type
+
+	Player    reflect.Type
+	NPC       reflect.Type
+	Dead      reflect.Type
+	Invisible reflect.Type
 }
 
 var Type = TypeDefinitions{
@@ -18,13 +25,33 @@ var Type = TypeDefinitions{
 	MapItem:         reflect.TypeOf((*MapItem)(nil)).Elem(),
 	MapItemBlock:    reflect.TypeOf((*MapItemBlock)(nil)).Elem(),
 	MapItemMovement: reflect.TypeOf((*MapItemMovement)(nil)).Elem(),
+	ChunkLoader:     reflect.TypeOf((*ChunkLoader)(nil)).Elem(),
+	Attached:        reflect.TypeOf((*Attached)(nil)).Elem(),
+
+	Player:    reflect.TypeOf((*Player)(nil)).Elem(),
+	NPC:       reflect.TypeOf((*NPC)(nil)).Elem(),
+	Dead:      reflect.TypeOf((*Dead)(nil)).Elem(),
+	Invisible: reflect.TypeOf((*Invisible)(nil)).Elem(),
 }
 
+// Registry contains stable names and wire ids of components, which are used in saved
+// entities, prefabs and network messages. Names and ids must never be changed or reused.
+var Registry = ecs.NewRegistry().
+	MustRegister("guid", 1, Type.GUID).
+	MustRegister("mapItem", 2, Type.MapItem).
+	MustRegister("mapItemBlock", 3, Type.MapItemBlock).
+	MustRegister("mapItemMovement", 4, Type.MapItemMovement).
+	MustRegister("chunkLoader", 5, Type.ChunkLoader).
+	MustRegister("attached", 6, Type.Attached).
+	MustRegister("player", 7, Type.Player).
+	MustRegister("npc", 8, Type.NPC).
+	MustRegister("dead", 9, Type.Dead).
+	MustRegister("invisible", 10, Type.Invisible)
+
 func RegisterComponents(manager *ecs.Manager) {
-	manager.RegisterComponents(
-		Type.GUID,
-		Type.MapItem,
-		Type.MapItemBlock,
-		Type.MapItemMovement,
-	)
+	manager.RegisterRegistry(Registry)
+	if err := manager.RegisterIndex(Type.GUID, NewGUIDIndex()); err != nil {
+		panic(err.Error())
+	}
+	RegisterRelations(manager)
 }
@@ -0,0 +1,36 @@
+package component
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestRegistryIsStable compares names and wire ids of registry with golden file, they're
+// used in saved data, so they must never change. Components can be only appended, go types
+// can be renamed.
+func TestRegistryIsStable(t *testing.T) {
+	var lines []string
+	for _, info := range Registry.Components() {
+		lines = append(lines, fmt.Sprintf("%v %v", info.WireID, info.Name))
+	}
+	data := []byte(strings.Join(lines, "\n") + "\n")
+	golden := "testdata/registry.golden"
+	if *update {
+		if err := ioutil.WriteFile(golden, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(data), bytes.TrimSpace(expected)) {
+		t.Fatalf("registry changed, expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
@@ -0,0 +1,21 @@
+package component
+
+import "github.com/Tomislaw/far-worlds/ecs"
+
+const (
+	// Targets relates entity to entities it attacks or follows
+	Targets ecs.RelationKind = "targets"
+	// OwnedBy relates item to its single owner
+	OwnedBy ecs.RelationKind = "ownedBy"
+	// MemberOf relates character to its guild
+	MemberOf ecs.RelationKind = "memberOf"
+	// EquippedIn relates item to character wearing it, relation data is name of slot
+	EquippedIn ecs.RelationKind = "equippedIn"
+)
+
+func RegisterRelations(manager *ecs.Manager) {
+	manager.RegisterRelation(Targets, false)
+	manager.RegisterRelation(OwnedBy, true)
+	manager.RegisterRelation(MemberOf, true)
+	manager.RegisterRelation(EquippedIn, true)
+}
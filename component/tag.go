@@ -0,0 +1,15 @@
+package component
+
+// Tags are zero-size components, they mark entities without storing any data
+
+// Player marks entity controlled by player
+type Player struct{}
+
+// NPC marks entity controlled by game
+type NPC struct{}
+
+// Dead marks killed creature
+type Dead struct{}
+
+// Invisible marks entity which shouldn't be seen by others
+type Invisible struct{}
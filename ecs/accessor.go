@@ -0,0 +1,79 @@
+package ecs
+
+// Typed accessors find component storage by nil pointer to component type, so they
+// don't use reflection and return values without type assertions in calling code.
+
+func storageOf[T any](manager *Manager) *Component {
+	return manager.componentKeys[(*T)(nil)]
+}
+
+// Get returns copy of component of entity, it's read access which doesn't mark component
+// as changed
+func Get[T any](entity *Entity) (T, bool) {
+	var zero T
+	if component := storageOf[T](entity.Manager()); component != nil && component.tag {
+		return zero, component.has(entity)
+	}
+	if pointer := pointerOf[T](entity); pointer != nil {
+		return *pointer, true
+	}
+	return zero, false
+}
+
+// GetMut returns pointer to component stored in manager or nil if entity doesn't have it.
+// It's write access: component is marked as changed on every call, even if it's only read
+// through pointer, so Get should be used for reads. Changes made through pointer are
+// visible immediately, observers are notified with value changed in place on next Flush.
+// Pointer is valid until component is added again or removed. Tags have no storage, so nil
+// is always returned for them. Keys of indexed components wouldn't be updated by changes in
+// place, so GetMut panics for them, they must be changed with Add or AddComponent.
+func GetMut[T any](entity *Entity) *T {
+	pointer := pointerOf[T](entity)
+	if pointer != nil {
+		component := storageOf[T](entity.Manager())
+		if component.index != nil {
+			panic("Changing indexed component " + component.reflectType.String() + " in place")
+		}
+		component.markChanged(entity.id, entity.Manager().nextTick())
+		if len(component.observers) > 0 {
+			entity.Manager().commandBuffer.changeComponent(entity, component.reflectType, pointer)
+		}
+	}
+	return pointer
+}
+
+// pointerOf returns stored pointer, like Has it doesn't return component which removal
+// isn't resolved yet
+func pointerOf[T any](entity *Entity) *T {
+	component := storageOf[T](entity.Manager())
+	if component == nil || !entity.hasFlag(component.id) {
+		return nil
+	}
+	component.datalock.RLock()
+	pointer, _ := component.data[entity.id].(*T)
+	component.datalock.RUnlock()
+	return pointer
+}
+
+// Has returns true if entity has component, like HasComponent it includes changes which
+// aren't resolved yet
+func Has[T any](entity *Entity) bool {
+	component := storageOf[T](entity.Manager())
+	if component == nil {
+		return false
+	}
+	return entity.hasFlag(component.id)
+}
+
+// Add adds component to entity, component is stored when manager is updated
+func Add[T any](entity *Entity, value T) *Entity {
+	component := storageOf[T](entity.Manager())
+	if component == nil {
+		return entity
+	}
+	pointer := new(T)
+	*pointer = value
+	entity.Manager().commandBuffer.addComponent(entity, component.reflectType, value, pointer)
+	entity.setFlag(component.id)
+	return entity
+}
@@ -0,0 +1,75 @@
+package ecs
+
+import (
+	"strconv"
+	"testing"
+)
+
+// recordingObserver records values of added components
+type recordingObserver struct {
+	added []interface{}
+}
+
+func (o *recordingObserver) ComponentAdded(entity *Entity, component interface{}) {
+	o.added = append(o.added, component)
+}
+
+func (o *recordingObserver) ComponentRemoved(entity *Entity) {}
+
+func TestGetMutNotifiesObservers(t *testing.T) {
+	manager := NewManager()
+	manager.RegisterComponents(testMovementType)
+	observer := &recordingObserver{}
+	manager.Observe(testMovementType, observer)
+	e := NewEntity(manager).AddComponent(testMovement{Speed: 1}).Register()
+	manager.Flush()
+
+	GetMut[testMovement](e).Speed = 2
+	GetMut[testMovement](e).Speed = 3
+	manager.Flush()
+	if len(observer.added) != 2 || observer.added[1] != (testMovement{Speed: 3}) {
+		t.Fatalf("expected observer to be notified once with changed value, got %v", observer.added)
+	}
+}
+
+func TestGetIgnoresRemovedComponents(t *testing.T) {
+	manager := NewManager()
+	manager.RegisterComponents(testMovementType)
+	e := NewEntity(manager).AddComponent(testMovement{Speed: 1}).Register()
+	manager.Flush()
+
+	e.RemoveComponent(testMovementType)
+	if _, ok := Get[testMovement](e); ok || GetMut[testMovement](e) != nil {
+		t.Fatal("expected component which is being removed not to be returned")
+	}
+	if Has[testMovement](e) {
+		t.Fatal("expected Has to be consistent with Get")
+	}
+}
+
+func TestIndexedComponentsChangedWithAdd(t *testing.T) {
+	manager := newTestManager()
+	manager.RegisterIndex(testGUIDType, NewIndex(func(c interface{}) string {
+		return strconv.Itoa(c.(testGUID).ID)
+	}))
+	e := NewEntity(manager).AddComponent(testGUID{1}).Register()
+	manager.Flush()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected GetMut of indexed component to panic")
+			}
+		}()
+		GetMut[testGUID](e).ID = 2
+	}()
+
+	Add(e, testGUID{2})
+	manager.Flush()
+	if found, ok := manager.Lookup(testGUIDType, "2"); !ok || found != e {
+		t.Fatal("expected entity to be found by changed key")
+	}
+	if _, ok := manager.Lookup(testGUIDType, "1"); ok {
+		t.Fatal("expected old key to be released")
+	}
+}
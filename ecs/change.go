@@ -0,0 +1,198 @@
+package ecs
+
+import (
+	"reflect"
+	"sort"
+	"sync/atomic"
+)
+
+// Change ticks: manager tick is incremented when queued changes are resolved, before each
+// system is updated, when component is changed through GetMut and when entities are
+// inserted or extracted outside of Update. Components remember ticks when they were added and last changed, so system which saves
+// Tick at the end of its update can find changes made since then, including changes made
+// by systems updated after it, but not its own ones.
+
+// removedHistory is count of ticks for which removed components are remembered
+const removedHistory = 1 << 12
+
+// componentTicks contains ticks when component of entity was added and last changed
+type componentTicks struct {
+	added   uint64
+	changed uint64
+}
+
+type removal struct {
+	entity uint64
+	tick   uint64
+}
+
+// Tick returns current change tick of manager
+func (w *Manager) Tick() uint64 {
+	return atomic.LoadUint64(&w.tick)
+}
+
+func (w *Manager) nextTick() uint64 {
+	return atomic.AddUint64(&w.tick, 1)
+}
+
+// set stores component of entity, marking it as added or changed. Pointer of tag is ignored.
+// Returns error if value can't be indexed.
+func (c *Component) set(entity *Entity, value interface{}, pointer interface{}, tick uint64) error {
+	c.datalock.Lock()
+	defer c.datalock.Unlock()
+	if c.index != nil {
+		if err := c.index.set(entity, value); err != nil {
+			return err
+		}
+	}
+	if !c.tag {
+		c.data[entity.id] = pointer
+	}
+	ticks, ok := c.ticks[entity.id]
+	if !ok {
+		ticks.added = tick
+	}
+	ticks.changed = tick
+	c.ticks[entity.id] = ticks
+	return nil
+}
+
+// remove deletes component of entity, returns copy of removed value. Key of component is
+// kept in index if release is false.
+func (c *Component) remove(entity *Entity, tick uint64, release bool) (interface{}, bool) {
+	c.datalock.Lock()
+	defer c.datalock.Unlock()
+	if _, ok := c.ticks[entity.id]; !ok {
+		return nil, false
+	}
+	value := c.valueOf(entity.id)
+	delete(c.data, entity.id)
+	delete(c.ticks, entity.id)
+	if c.index != nil && release {
+		c.index.remove(entity)
+	}
+	c.removed = append(c.removed, removal{entity: entity.id, tick: tick})
+	return value, true
+}
+
+// markChanged marks component of entity as changed in place
+func (c *Component) markChanged(entity uint64, tick uint64) {
+	c.datalock.Lock()
+	defer c.datalock.Unlock()
+	if ticks, ok := c.ticks[entity]; ok {
+		ticks.changed = tick
+		c.ticks[entity] = ticks
+	}
+}
+
+// pruneRemoved forgets removals older than removedHistory ticks
+func (c *Component) pruneRemoved(tick uint64) {
+	c.datalock.Lock()
+	defer c.datalock.Unlock()
+	i := 0
+	for i < len(c.removed) && c.removed[i].tick+removedHistory < tick {
+		i++
+	}
+	c.removed = c.removed[i:]
+}
+
+// AddedSince returns true if component was added to entity after tick
+func (w *Manager) AddedSince(e *Entity, componentType reflect.Type, tick uint64) bool {
+	ticks, ok := w.componentTicks(e, componentType)
+	return ok && ticks.added > tick
+}
+
+// ChangedSince returns true if component was added or changed after tick
+func (w *Manager) ChangedSince(e *Entity, componentType reflect.Type, tick uint64) bool {
+	ticks, ok := w.componentTicks(e, componentType)
+	return ok && ticks.changed > tick
+}
+
+func (w *Manager) componentTicks(e *Entity, componentType reflect.Type) (componentTicks, bool) {
+	component := w.GetComponentType(componentType)
+	if component == nil {
+		return componentTicks{}, false
+	}
+	component.datalock.RLock()
+	defer component.datalock.RUnlock()
+	ticks, ok := component.ticks[e.id]
+	return ticks, ok
+}
+
+// RemovedSince returns ids of entities which lost component after tick, including removed
+// entities. Removals are remembered for limited number of ticks.
+func (w *Manager) RemovedSince(componentType reflect.Type, tick uint64) []uint64 {
+	component := w.GetComponentType(componentType)
+	if component == nil {
+		return nil
+	}
+	component.datalock.RLock()
+	defer component.datalock.RUnlock()
+	var result []uint64
+	for _, r := range component.removed {
+		if r.tick > tick {
+			result = append(result, r.entity)
+		}
+	}
+	return result
+}
+
+// Filter selects entities returned by Query
+type Filter func(w *Manager, e *Entity) bool
+
+// With selects entities having component, like Get it doesn't match components which adding
+// isn't resolved yet or which removal is queued
+func With(componentType reflect.Type) Filter {
+	return func(w *Manager, e *Entity) bool {
+		component := w.GetComponentType(componentType)
+		return component != nil && component.has(e)
+	}
+}
+
+// Without selects entities which aren't matched by With
+func Without(componentType reflect.Type) Filter {
+	return func(w *Manager, e *Entity) bool {
+		component := w.GetComponentType(componentType)
+		return component == nil || !component.has(e)
+	}
+}
+
+// Added selects entities which got component after tick
+func Added(componentType reflect.Type, since uint64) Filter {
+	return func(w *Manager, e *Entity) bool {
+		return w.AddedSince(e, componentType, since)
+	}
+}
+
+// Changed selects entities which component was added or changed after tick
+func Changed(componentType reflect.Type, since uint64) Filter {
+	return func(w *Manager, e *Entity) bool {
+		return w.ChangedSince(e, componentType, since)
+	}
+}
+
+// Query returns entities matching all filters sorted by id
+func (w *Manager) Query(filters ...Filter) []*Entity {
+	w.entityLock.RLock()
+	entities := make([]*Entity, 0, len(w.entites))
+	for _, e := range w.entites {
+		entities = append(entities, e)
+	}
+	w.entityLock.RUnlock()
+
+	result := entities[:0]
+	for _, e := range entities {
+		matches := true
+		for _, filter := range filters {
+			if !filter(w, e) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	return result
+}
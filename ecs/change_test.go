@@ -0,0 +1,150 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+// changeSystem records changes made since its previous update
+type changeSystem struct {
+	manager *Manager
+	last    uint64
+
+	added   []*Entity
+	changed []*Entity
+	removed []uint64
+}
+
+func (s *changeSystem) New(manager *Manager) { s.manager = manager }
+func (s *changeSystem) Remove(e *Entity)     {}
+func (s *changeSystem) Update(dt float32) {
+	s.added = s.manager.Query(Added(testGUIDType, s.last))
+	s.changed = s.manager.Query(Changed(testMovementType, s.last))
+	s.removed = s.manager.RemovedSince(testGUIDType, s.last)
+	s.last = s.manager.Tick()
+}
+
+func newChangeManager() (*Manager, *changeSystem) {
+	manager := NewManager()
+	manager.RegisterComponents(testGUIDType, testMovementType)
+	system := &changeSystem{}
+	manager.RegisterSystem(system)
+	return manager, system
+}
+
+func TestAddedAndChanged(t *testing.T) {
+	manager, system := newChangeManager()
+	e := NewEntity(manager).AddComponent(testGUID{ID: 1}).AddComponent(testMovement{}).Register()
+
+	manager.Update(0)
+	if !reflect.DeepEqual(system.added, []*Entity{e}) || !reflect.DeepEqual(system.changed, []*Entity{e}) {
+		t.Fatalf("expected entity to be added, got added %v and changed %v", system.added, system.changed)
+	}
+
+	manager.Update(0)
+	if len(system.added) != 0 || len(system.changed) != 0 {
+		t.Fatalf("expected no changes, got added %v and changed %v", system.added, system.changed)
+	}
+
+	e.AddComponent(testMovement{Speed: 2})
+	manager.Update(0)
+	if len(system.added) != 0 || !reflect.DeepEqual(system.changed, []*Entity{e}) {
+		t.Fatalf("expected movement to be changed, got added %v and changed %v", system.added, system.changed)
+	}
+}
+
+func TestRemoved(t *testing.T) {
+	manager, system := newChangeManager()
+	e := NewEntity(manager).AddComponent(testGUID{ID: 1}).Register()
+	e2 := NewEntity(manager).AddComponent(testGUID{ID: 2}).Register()
+	manager.Update(0)
+
+	e.RemoveComponent(testGUIDType)
+	manager.Update(0)
+	if !reflect.DeepEqual(system.removed, []uint64{e.ID()}) {
+		t.Fatalf("expected guid of %v to be removed, got %v", e.ID(), system.removed)
+	}
+
+	e2.Remove()
+	manager.Update(0)
+	if !reflect.DeepEqual(system.removed, []uint64{e2.ID()}) {
+		t.Fatalf("expected guid of %v to be removed, got %v", e2.ID(), system.removed)
+	}
+}
+
+func TestChangesBetweenUpdates(t *testing.T) {
+	manager, system := newChangeManager()
+	other := NewManager()
+	other.RegisterComponents(testGUIDType, testMovementType)
+	e := NewEntity(other).AddComponent(testGUID{ID: 1}).Register()
+	other.Update(0)
+	manager.Update(0)
+
+	if err := manager.InsertEntity(e, other.ExtractEntity(e)); err != nil {
+		t.Fatal(err)
+	}
+	manager.Update(0)
+	if !reflect.DeepEqual(system.added, []*Entity{e}) {
+		t.Fatalf("expected inserted entity to be added, got %v", system.added)
+	}
+
+	manager.ExtractEntity(e)
+	manager.Update(0)
+	if !reflect.DeepEqual(system.removed, []uint64{e.ID()}) {
+		t.Fatalf("expected extracted entity to be removed, got %v", system.removed)
+	}
+}
+
+func TestGetMutMarksChanged(t *testing.T) {
+	manager, system := newChangeManager()
+	e := NewEntity(manager).AddComponent(testMovement{}).Register()
+	manager.Update(0)
+	manager.Update(0)
+
+	Get[testMovement](e)
+	manager.Update(0)
+	if len(system.changed) != 0 {
+		t.Fatalf("expected read not to change movement, got %v", system.changed)
+	}
+
+	GetMut[testMovement](e).Speed = 3
+	manager.Update(0)
+	if !reflect.DeepEqual(system.changed, []*Entity{e}) {
+		t.Fatalf("expected movement to be changed, got %v", system.changed)
+	}
+}
+
+type testTag struct{}
+
+var testTagType = reflect.TypeOf(testTag{})
+
+func TestTags(t *testing.T) {
+	manager := NewManager()
+	manager.RegisterComponents(testTagType)
+	e := NewEntity(manager).Register()
+	manager.Update(0)
+	since := manager.Tick()
+
+	e.AddComponent(testTag{})
+	if len(manager.Query(With(testTagType))) != 0 || len(manager.Query(Without(testTagType))) != 1 {
+		t.Fatal("expected tag not to be matched before it's resolved")
+	}
+	if _, ok := Get[testTag](e); ok {
+		t.Fatal("expected Get to be consistent with With")
+	}
+	manager.Update(0)
+	if !reflect.DeepEqual(manager.Query(With(testTagType)), []*Entity{e}) {
+		t.Fatal("expected resolved tag to be matched")
+	}
+	if !reflect.DeepEqual(manager.Query(Added(testTagType, since)), []*Entity{e}) {
+		t.Fatal("expected added tag to be detected")
+	}
+	if len(manager.GetComponentType(testTagType).data) != 0 {
+		t.Fatal("expected tag to have no data")
+	}
+
+	e.RemoveComponent(testTagType)
+	if len(manager.Query(With(testTagType))) != 0 || len(manager.Query(Without(testTagType))) != 1 {
+		t.Fatal("expected removed tag not to be matched")
+	}
+}
@@ -1,94 +1,140 @@
 package ecs
 
 import (
-	"fmt"
 	"reflect"
 	"sync"
 )
 
+// commandBuffer queues changes made from any goroutine until they are resolved on
+// goroutine updating manager. Commands are resolved in order they were queued.
 type commandBuffer struct {
-	componentsToAdd     sync.Pool // component
-	componentsToDestroy sync.Pool // component
+	lock sync.Mutex
 
-	entitesToAdd     sync.Pool // *Entity
-	entitesToDestroy sync.Pool // *Entity
+	components []component
 
+	entitesToAdd     []*Entity
+	entitesToDestroy []*Entity
 }
 
 type component struct {
 	reflectType reflect.Type
-	entityID    uint64
-	data        *interface{}
+	entity      *Entity
+	remove      bool
+	// changed component was modified in place through pointer, observers are notified
+	changed bool
+	// value is passed to observers, pointer is stored and created from value if nil
+	value   interface{}
+	pointer interface{}
 }
 
 func (b *commandBuffer) removeEntity(e *Entity) {
-	b.entitesToDestroy.Put(e)
-	for _, item := range e.GetComponentsTypes() {
-		b.removeComponent(e, item)
-	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.entitesToDestroy = append(b.entitesToDestroy, e)
 }
 
 func (b *commandBuffer) addEntity(e *Entity) {
-	b.entitesToAdd.Put(e)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.entitesToAdd = append(b.entitesToAdd, e)
 }
 
 func (b *commandBuffer) removeComponent(e *Entity, t reflect.Type) {
-	b.componentsToDestroy.Put(component{reflectType: t, entityID: e.id})
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.components = append(b.components, component{reflectType: t, entity: e, remove: true})
 }
 
-func (b *commandBuffer) addComponent(e *Entity, c *interface{}) {
+func (b *commandBuffer) addComponent(e *Entity, t reflect.Type, value interface{}, pointer interface{}) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.components = append(b.components, component{reflectType: t, entity: e, value: value, pointer: pointer})
+}
 
-	ctype := reflect.TypeOf(reflect.ValueOf(c).Elem().Interface())
-	b.componentsToAdd.Put(component{reflectType: ctype, entityID: e.id, data: c})
+func (b *commandBuffer) changeComponent(e *Entity, t reflect.Type, pointer interface{}) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.components = append(b.components, component{reflectType: t, entity: e, changed: true, pointer: pointer})
 }
 
-func (b *commandBuffer) resolveEntites(entites entites) {
+func (b *commandBuffer) empty() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.components) == 0 && len(b.entitesToAdd) == 0 && len(b.entitesToDestroy) == 0
+}
 
-	for {
-		e := b.entitesToAdd.Get()
-		if e == nil {
-			break
-		}
-		entityToAdd := e.(*Entity)
-		entites[entityToAdd.id] = entityToAdd
-		fmt.Printf("Entity added - id: %v\n", entityToAdd.id)
+// take removes all queued commands, so commands queued while resolving wait for next resolve
+func (b *commandBuffer) take() ([]component, []*Entity, []*Entity) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	components, added, destroyed := b.components, b.entitesToAdd, b.entitesToDestroy
+	b.components, b.entitesToAdd, b.entitesToDestroy = nil, nil, nil
+	return components, added, destroyed
+}
+
+// resolve applies queued commands, components are resolved before entities, so removed
+// entity loses also components added in the same tick. Removed entities lose descendants.
+func (b *commandBuffer) resolve(w *Manager) {
+	components, added, destroyed := b.take()
+	tick := w.Tick()
+
+	type changeKey struct {
+		entity      *Entity
+		reflectType reflect.Type
 	}
+	notified := make(map[changeKey]bool)
+	for _, c := range components {
+		componentData := w.components[c.reflectType]
+		if c.changed {
+			// component changed many times is reported once, if it's still stored
+			key := changeKey{c.entity, c.reflectType}
+			if notified[key] || !componentData.stores(c.entity.id, c.pointer) {
+				continue
+			}
+			notified[key] = true
+			value := valueOf(c.pointer)
+			for _, observer := range componentData.observers {
+				observer.ComponentAdded(c.entity, value)
+			}
+			continue
+		}
+		if c.remove {
+			if _, ok := componentData.remove(c.entity, tick, true); !ok {
+				continue
+			}
+			w.logf("Component removed - entity: %v, type: %v\n", c.entity.id, c.reflectType)
+			for _, observer := range componentData.observers {
+				observer.ComponentRemoved(c.entity)
+			}
+			continue
+		}
 
-	for {
-		e := b.entitesToDestroy.Get()
-		if e == nil {
-			break
+		pointer := c.pointer
+		if pointer == nil && !componentData.tag {
+			pointer = newPointer(c.value)
+		}
+		if err := componentData.set(c.entity, c.value, pointer, tick); err != nil {
+			w.Errorf("Component rejected - entity: %v, type: %v, %v\n", c.entity.id, c.reflectType, err)
+			if !componentData.stored(c.entity.id) {
+				c.entity.clearFlag(componentData.id)
+			}
+			continue
+		}
+		w.logf("Component added to entity: %v, type: %v\n", c.entity.id, c.reflectType)
+		for _, observer := range componentData.observers {
+			observer.ComponentAdded(c.entity, c.value)
 		}
-		entityToRemove := e.(*Entity)
-		entites[entityToRemove.id] = nil
-		fmt.Printf("Entity removed - id: %v\n", entityToRemove.id)
 	}
-	return
-}
 
-func (b *commandBuffer) resolveComponents(components components) {
-
-	for {
-		c := b.componentsToAdd.Get()
-		if c == nil {
-			break
-		}
-		componentToAdd := c.(component)
-		componentData := components[componentToAdd.reflectType]
-		componentData.data[componentToAdd.entityID] = componentToAdd.data
-		fmt.Printf("Component added to entity: %v, type: %v\n", componentToAdd.entityID, componentToAdd.reflectType)
-		//componentdata.
-		//.data[componentToAdd.entityID] = componentToAdd.data
+	w.entityLock.Lock()
+	for _, e := range added {
+		w.entites[e.id] = e
+		w.logf("Entity added - id: %v\n", e.id)
 	}
+	w.entityLock.Unlock()
 
-	for {
-		c := b.componentsToDestroy.Get()
-		if c == nil {
-			break
-		}
-		componentToRemove := c.(component)
-		components[componentToRemove.reflectType].data[componentToRemove.entityID] = nil
-		fmt.Printf("Component removed - entity: %v, type: %v\n", componentToRemove.entityID, componentToRemove.reflectType)
+	for _, e := range destroyed {
+		w.destroy(e)
+		w.logf("Entity removed - id: %v\n", e.id)
 	}
-	return
 }
@@ -1,7 +1,6 @@
 package ecs
 
 import (
-	"fmt"
 	"reflect"
 	"sync"
 )
@@ -9,9 +8,85 @@ import (
 // Component contains component type and data for each entity of this component type
 // Maxiumum component count is 64
 type Component struct {
-	id       uint8
+	id          uint8
+	reflectType reflect.Type
+	// tag components are zero-size markers, they have no data and their presence is read from
+	// entity flags, ticks are kept for them only for change detection
+	tag      bool
 	datalock *sync.RWMutex
-	data     map[uint64]*interface{}
+	// data contains pointers to component values, so they can be modified in place
+	data      map[uint64]interface{}
+	observers []ComponentObserver
+	// ticks and removed are used for change detection, see Tick
+	ticks   map[uint64]componentTicks
+	removed []removal
+	index   *Index
+}
+
+// newPointer copies component value to newly allocated storage
+func newPointer(value interface{}) interface{} {
+	pointer := reflect.New(reflect.TypeOf(value))
+	pointer.Elem().Set(reflect.ValueOf(value))
+	return pointer.Interface()
+}
+
+// valueOf returns copy of component value stored under pointer
+func valueOf(pointer interface{}) interface{} {
+	return reflect.ValueOf(pointer).Elem().Interface()
+}
+
+// get returns copy of component value of entity
+func (c *Component) get(entity *Entity) (interface{}, bool) {
+	if c.tag {
+		if !entity.hasFlag(c.id) {
+			return nil, false
+		}
+		return reflect.Zero(c.reflectType).Interface(), true
+	}
+	c.datalock.RLock()
+	defer c.datalock.RUnlock()
+	if _, ok := c.data[entity.id]; !ok {
+		return nil, false
+	}
+	return c.valueOf(entity.id), true
+}
+
+// valueOf returns copy of stored component value, caller must hold datalock
+func (c *Component) valueOf(entity uint64) interface{} {
+	if c.tag {
+		return reflect.Zero(c.reflectType).Interface()
+	}
+	return valueOf(c.data[entity])
+}
+
+// stores returns true if pointer is stored as component of entity
+func (c *Component) stores(entity uint64, pointer interface{}) bool {
+	c.datalock.RLock()
+	defer c.datalock.RUnlock()
+	return c.data[entity] == pointer
+}
+
+// has returns true if adding component to entity was resolved and its removal isn't queued,
+// tags and components with data are checked the same way
+func (c *Component) has(entity *Entity) bool {
+	return entity.hasFlag(c.id) && c.stored(entity.id)
+}
+
+// stored returns true if adding component to entity was resolved and it wasn't removed since
+func (c *Component) stored(entity uint64) bool {
+	c.datalock.RLock()
+	defer c.datalock.RUnlock()
+	_, ok := c.ticks[entity]
+	return ok
+}
+
+// ComponentObserver is notified when component is added to or removed from entity.
+// Adding component which entity already has, or changing it through GetMut, is reported
+// as added again.
+// Observers are called from Manager.Update, before systems are updated.
+type ComponentObserver interface {
+	ComponentAdded(entity *Entity, component interface{})
+	ComponentRemoved(entity *Entity)
 }
 
 type components map[reflect.Type]*Component
@@ -22,29 +97,29 @@ func (s components) Len() int {
 
 // AddComponent adds any object to entity
 func (entity *Entity) AddComponent(component interface{}) *Entity {
-	ctype := entity.manager.GetComponentType(reflect.TypeOf(component))
+	ctype := entity.Manager().GetComponentType(reflect.TypeOf(component))
 
 	if ctype == nil {
 		return entity
 	}
 
-	entity.manager.commandBuffer.addComponent(entity, &component)
-	entity.componentFlags |= 1 << ctype.id
+	entity.Manager().commandBuffer.addComponent(entity, reflect.TypeOf(component), component, nil)
+	entity.setFlag(ctype.id)
 
 	return entity
 }
 
 //
 func (entity *Entity) RemoveComponent(typeof reflect.Type) *Entity {
-	ctype := entity.manager.GetComponentType(typeof)
+	ctype := entity.Manager().GetComponentType(typeof)
 
 	if ctype == nil {
-		fmt.Printf("Trying to add unregistered component %v\n", typeof)
+		entity.Manager().Errorf("Trying to remove unregistered component %v\n", typeof)
 		return entity
 	}
 
-	entity.manager.commandBuffer.removeComponent(entity, typeof)
-	entity.componentFlags &= ^(1 << ctype.id)
+	entity.Manager().commandBuffer.removeComponent(entity, typeof)
+	entity.clearFlag(ctype.id)
 	return entity
 }
 
@@ -57,7 +132,8 @@ func (entity *Entity) AddComponents(components ...interface{}) *Entity {
 	return entity
 }
 
-// GetComponent return component assignet to this entity or nil if not found
+// GetComponent returns copy of component value assigned to this entity or nil if not found.
+// Use Get and GetMut to access components without type assertions and copying.
 func (entity *Entity) GetComponent(componentType reflect.Type) interface{} {
 
 	ok := entity.HasComponent(componentType)
@@ -65,32 +141,27 @@ func (entity *Entity) GetComponent(componentType reflect.Type) interface{} {
 		return nil
 	}
 
-	component, ok := entity.manager.components[componentType]
+	component, ok := entity.Manager().components[componentType]
 	if !ok {
 		return nil
 	}
 
-	data, ok := component.data[entity.id]
-	if !ok {
-		return nil
-	}
-	return data
+	value, _ := component.get(entity)
+	return value
 }
 
-func (entity *Entity) GetComponents() []*interface{} {
+// GetComponents returns copies of all component values assigned to this entity
+func (entity *Entity) GetComponents() []interface{} {
 
-	var components []*interface{}
+	var components []interface{}
 
-	for _, component := range entity.manager.components {
-
-		component.datalock.RLock()
-		item, ok := component.data[entity.id]
+	for _, component := range entity.Manager().components {
 
+		value, ok := component.get(entity)
 		if !ok {
-			component.datalock.RUnlock()
 			continue
 		}
-		components = append(components, item)
+		components = append(components, value)
 	}
 	return components
 }
@@ -99,13 +170,9 @@ func (entity *Entity) GetComponentsTypes() []reflect.Type {
 
 	var components []reflect.Type
 
-	for ctype, component := range entity.manager.components {
-
-		component.datalock.RLock()
-		_, ok := component.data[entity.id]
+	for ctype, component := range entity.Manager().components {
 
-		if !ok {
-			component.datalock.RUnlock()
+		if !component.has(entity) {
 			continue
 		}
 		components = append(components, ctype)
@@ -115,11 +182,9 @@ func (entity *Entity) GetComponentsTypes() []reflect.Type {
 
 // HasComponent returns true if contains component
 func (entity *Entity) HasComponent(componentType reflect.Type) bool {
-	ctype := entity.manager.GetComponentType(componentType)
+	ctype := entity.Manager().GetComponentType(componentType)
 	if ctype == nil {
 		return false
 	}
-	id := ctype.id
-	var flag uint64 = 1 << id
-	return entity.componentFlags&flag == flag
+	return entity.hasFlag(ctype.id)
 }
@@ -17,7 +17,9 @@ type Entity struct {
 	componentFlags uint64
 	parent         *Entity
 	children       []*Entity
-	manager        *Manager
+	// manager contains *Manager, it's changed when entity is inserted into another manager
+	// and read from goroutines of other managers, so it's accessed atomically
+	manager atomic.Value
 }
 
 // Identifier is an interface for anything that implements the basic ID() uint64,
@@ -34,7 +36,9 @@ type IdentifierSlice []Identifier
 // NewEntity creates a new Entity with a new unique identifier. It is safe for
 // concurrent use.
 func NewEntity(manager *Manager) *Entity {
-	return &Entity{id: atomic.AddUint64(&idInc, 1), manager: manager}
+	e := &Entity{id: atomic.AddUint64(&idInc, 1)}
+	e.manager.Store(manager)
+	return e
 }
 
 // NewEntities creates an amount of new entities with a new unique identifiers. It
@@ -47,80 +51,71 @@ func NewEntities(manager *Manager, amount int) []*Entity {
 	for i := 0; i < amount; i++ {
 		entities[i] = &Entity{}
 		entities[i].id = lastID - uint64(amount) + uint64(i) + 1
-		entities[i].manager = manager
+		entities[i].manager.Store(manager)
 	}
 
 	return entities
 }
 
-// ID returns the unique identifier of the entity.
-func (e Entity) ID() uint64 {
+// ID returns the unique identifier of the entity. Pointer receiver avoids copying flags,
+// which are changed concurrently.
+func (e *Entity) ID() uint64 {
 	return e.id
 }
 
-// GetEntity returns a Pointer to the BasicEntity itself
-// By having this method, All Entities containing a BasicEntity now automatically have a GetEntity Method
-// This allows system.Add functions to recieve a single interface
-//}
-func (e *Entity) GetEntity() *Entity {
-	return e
-}
-
-// AppendChild appends a child to the BasicEntity
-func (e *Entity) AppendChild(child *Entity) {
-	child.parent = e
-	e.children = append(e.children, child)
+// Manager returns manager which entity belongs to
+func (e *Entity) Manager() *Manager {
+	manager, _ := e.manager.Load().(*Manager)
+	return manager
 }
 
-func (e *Entity) RemoveChild(child *Entity) {
-	delete := -1
-	for i, v := range e.children {
-		if v.ID() == child.ID() {
-			delete = i
-			break
+// setFlag marks component as added, flags are changed atomically as components can be
+// added from many goroutines
+func (e *Entity) setFlag(id uint8) {
+	for {
+		flags := atomic.LoadUint64(&e.componentFlags)
+		if atomic.CompareAndSwapUint64(&e.componentFlags, flags, flags|1<<id) {
+			return
 		}
 	}
-	if delete >= 0 {
-		e.children = append(e.children[:delete], e.children[delete+1:]...)
-	}
 }
 
-// Children returns the children of the BasicEntity
-func (e *Entity) Children() []Entity {
-	ret := []Entity{}
-	for _, child := range e.children {
-		ret = append(ret, *child)
+// clearFlag marks component as removed
+func (e *Entity) clearFlag(id uint8) {
+	for {
+		flags := atomic.LoadUint64(&e.componentFlags)
+		if atomic.CompareAndSwapUint64(&e.componentFlags, flags, flags&^(1<<id)) {
+			return
+		}
 	}
-	return ret
 }
 
-// Descendents returns the children and their children all the way down the tree.
-func (e *Entity) Descendents() []Entity {
-	return descendents([]Entity{}, e, e)
+// hasFlag returns true if component is marked as added
+func (e *Entity) hasFlag(id uint8) bool {
+	var flag uint64 = 1 << id
+	return atomic.LoadUint64(&e.componentFlags)&flag == flag
 }
 
-func (e *Entity) Remove() {
-	e.manager.commandBuffer.removeEntity(e)
+func (e *Entity) resetFlags() {
+	atomic.StoreUint64(&e.componentFlags, 0)
 }
 
-func (e *Entity) Register() *Entity {
-	e.manager.commandBuffer.addEntity(e)
+// GetEntity returns a Pointer to the BasicEntity itself
+// By having this method, All Entities containing a BasicEntity now automatically have a GetEntity Method
+// This allows system.Add functions to recieve a single interface
+//}
+func (e *Entity) GetEntity() *Entity {
 	return e
 }
 
-func descendents(in []Entity, this, top *Entity) []Entity {
-	for _, child := range this.children {
-		in = descendents(in, child, top)
-	}
-	if this.ID() == top.ID() {
-		return in
-	}
-	return append(in, *this)
+// Remove removes entity together with all its descendants on next update
+func (e *Entity) Remove() {
+	e.Manager().commandBuffer.removeEntity(e)
 }
 
-// Parent returns the parent of the BasicEntity
-func (e *Entity) Parent() *Entity {
-	return e.parent
+func (e *Entity) Register() *Entity {
+	e.Manager().commandBuffer.addEntity(e)
+	return e
 }
 
 // Len returns the length of the underlying slice
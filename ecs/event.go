@@ -0,0 +1,131 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+)
+
+// eventBuffer contains events sent during single tick, first has sequence number start
+type eventBuffer struct {
+	start  uint64
+	events []interface{}
+}
+
+// Events is queue of events of single type. Events are double buffered: event sent during
+// tick can be read during that tick and the next one, after that it's dropped. Safe for
+// concurrent use.
+type Events struct {
+	lock sync.Mutex
+	// older contains events of previous tick, newer events of current one
+	older eventBuffer
+	newer eventBuffer
+	count uint64
+}
+
+// EventReader reads events of single type, each reader receives every event once
+type EventReader struct {
+	events *Events
+	next   uint64
+}
+
+// Send adds event to queue
+func (e *Events) Send(event interface{}) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.newer.events = append(e.newer.events, event)
+	e.count++
+}
+
+// Reader creates reader which receives events sent after it was created
+func (e *Events) Reader() *EventReader {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return &EventReader{events: e, next: e.count}
+}
+
+// Len returns count of events which weren't dropped yet
+func (e *Events) Len() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return len(e.older.events) + len(e.newer.events)
+}
+
+// update drops events of previous tick, called at the beginning of tick
+func (e *Events) update() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.older, e.newer = e.newer, e.older
+	e.newer.start = e.count
+	e.newer.events = e.newer.events[:0]
+}
+
+// Read returns events sent since last read in order they were sent. Events which were
+// dropped before reading are skipped.
+func (r *EventReader) Read() []interface{} {
+	e := r.events
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var result []interface{}
+	for _, buffer := range []*eventBuffer{&e.older, &e.newer} {
+		for i, event := range buffer.events {
+			if buffer.start+uint64(i) >= r.next {
+				result = append(result, event)
+			}
+		}
+	}
+	r.next = e.count
+	return result
+}
+
+// RegisterEvent registers type of events which can be sent by systems
+func (w *Manager) RegisterEvent(eventType reflect.Type) *Manager {
+	w.eventLock.Lock()
+	defer w.eventLock.Unlock()
+	if _, ok := w.events[eventType]; !ok {
+		w.events[eventType] = &Events{}
+	}
+	return w
+}
+
+// RegisterEvents registers multiple event types
+func (w *Manager) RegisterEvents(eventTypes ...reflect.Type) *Manager {
+	for _, eventType := range eventTypes {
+		w.RegisterEvent(eventType)
+	}
+	return w
+}
+
+// Events returns queue of events of given type or nil if type isn't registered
+func (w *Manager) Events(eventType reflect.Type) *Events {
+	w.eventLock.RLock()
+	defer w.eventLock.RUnlock()
+	return w.events[eventType]
+}
+
+// EventReader creates reader of events of registered type
+func (w *Manager) EventReader(eventType reflect.Type) *EventReader {
+	events := w.Events(eventType)
+	if events == nil {
+		panic("Reading unregistered event " + eventType.String())
+	}
+	return events.Reader()
+}
+
+// Emit sends event of registered type
+func (w *Manager) Emit(event interface{}) {
+	events := w.Events(reflect.TypeOf(event))
+	if events == nil {
+		w.Errorf("Trying to emit unregistered event %v\n", reflect.TypeOf(event))
+		return
+	}
+	events.Send(event)
+}
+
+func (w *Manager) updateEvents() {
+	w.eventLock.RLock()
+	defer w.eventLock.RUnlock()
+	for _, events := range w.events {
+		events.update()
+	}
+}
@@ -0,0 +1,57 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type testEvent struct{ id int }
+
+var testEventType = reflect.TypeOf(testEvent{})
+
+func TestEventsVisibleForTwoTicks(t *testing.T) {
+	manager := NewManager().RegisterEvent(testEventType)
+	early := manager.EventReader(testEventType)
+	late := manager.EventReader(testEventType)
+	dropped := manager.EventReader(testEventType)
+
+	manager.Update(0)
+	manager.Emit(testEvent{1})
+	after := manager.EventReader(testEventType)
+	if events := early.Read(); !reflect.DeepEqual(events, []interface{}{testEvent{1}}) {
+		t.Fatalf("expected event to be read in tick it was sent, got %v", events)
+	}
+	if events := after.Read(); len(events) != 0 {
+		t.Fatalf("expected reader not to receive events sent before it was created, got %v", events)
+	}
+
+	manager.Update(0)
+	manager.Emit(testEvent{2})
+	if events := late.Read(); !reflect.DeepEqual(events, []interface{}{testEvent{1}, testEvent{2}}) {
+		t.Fatalf("expected events of previous and current tick, got %v", events)
+	}
+	if events := early.Read(); !reflect.DeepEqual(events, []interface{}{testEvent{2}}) {
+		t.Fatalf("expected each event to be read once, got %v", events)
+	}
+
+	manager.Update(0)
+	if events := dropped.Read(); !reflect.DeepEqual(events, []interface{}{testEvent{2}}) {
+		t.Fatalf("expected events older than previous tick to be dropped, got %v", events)
+	}
+	manager.Update(0)
+	if n := manager.Events(testEventType).Len(); n != 0 {
+		t.Fatalf("expected all events to be dropped, got %v", n)
+	}
+}
+
+func TestEmitUnregisteredEventIsLogged(t *testing.T) {
+	var logged []string
+	manager := NewManager().SetLogger(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	manager.Emit(testEvent{1})
+	if len(logged) != 1 {
+		t.Fatalf("expected unregistered event to be logged, got %v", logged)
+	}
+}
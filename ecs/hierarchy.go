@@ -0,0 +1,130 @@
+package ecs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Hierarchy functions change entities immediately, so like Update they can't be called
+// concurrently with Update.
+
+// ErrHierarchyCycle is returned when entity would become its own ancestor
+var ErrHierarchyCycle = errors.New("entity can't be descendant of itself")
+
+// HierarchyObserver is notified when parent of entity changes
+type HierarchyObserver interface {
+	ParentChanged(entity *Entity, oldParent *Entity)
+}
+
+// ObserveHierarchy registers observer of parent changes
+func (w *Manager) ObserveHierarchy(observer HierarchyObserver) *Manager {
+	w.hierarchyObservers = append(w.hierarchyObservers, observer)
+	return w
+}
+
+// Parent returns parent of entity or nil
+func (e *Entity) Parent() *Entity {
+	return e.parent
+}
+
+// Root returns topmost ancestor of entity, entity without parent is its own root
+func (e *Entity) Root() *Entity {
+	root := e
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
+// Children returns children of entity in order
+func (e *Entity) Children() []*Entity {
+	return append([]*Entity(nil), e.children...)
+}
+
+// Descendents returns children and their children all the way down the tree, each
+// entity is followed by its descendants
+func (e *Entity) Descendents() []*Entity {
+	var result []*Entity
+	for _, child := range e.children {
+		result = append(result, child)
+		result = append(result, child.Descendents()...)
+	}
+	return result
+}
+
+// IsAncestorOf returns true if entity is parent of other entity or parent of its ancestor
+func (e *Entity) IsAncestorOf(other *Entity) bool {
+	for p := other.parent; p != nil; p = p.parent {
+		if p == e {
+			return true
+		}
+	}
+	return false
+}
+
+// SetParent moves entity to the end of children of parent, nil parent detaches entity
+func (e *Entity) SetParent(parent *Entity) error {
+	if parent == nil {
+		return e.setParent(nil, 0)
+	}
+	return e.setParent(parent, len(parent.children))
+}
+
+// AppendChild moves child to the end of children of entity
+func (e *Entity) AppendChild(child *Entity) error {
+	return child.SetParent(e)
+}
+
+// InsertChild moves child to index in children of entity, reordering child which already
+// belongs to entity
+func (e *Entity) InsertChild(child *Entity, index int) error {
+	return child.setParent(e, index)
+}
+
+// RemoveChild detaches child from entity, child isn't removed from manager
+func (e *Entity) RemoveChild(child *Entity) {
+	if child.parent == e {
+		child.setParent(nil, 0)
+	}
+}
+
+func (e *Entity) setParent(parent *Entity, index int) error {
+	if parent != nil {
+		if parent == e || e.IsAncestorOf(parent) {
+			return ErrHierarchyCycle
+		}
+		if parent.Manager() != e.Manager() {
+			return fmt.Errorf("entity %v and parent %v belong to different managers", e.id, parent.id)
+		}
+	}
+
+	old := e.parent
+	if old != nil {
+		old.children = removeEntity(old.children, e)
+	}
+	e.parent = parent
+	if parent != nil {
+		if index < 0 || index > len(parent.children) {
+			index = len(parent.children)
+		}
+		parent.children = append(parent.children, nil)
+		copy(parent.children[index+1:], parent.children[index:])
+		parent.children[index] = e
+	}
+
+	if old != parent {
+		for _, observer := range e.Manager().hierarchyObservers {
+			observer.ParentChanged(e, old)
+		}
+	}
+	return nil
+}
+
+func removeEntity(entities []*Entity, e *Entity) []*Entity {
+	for i, item := range entities {
+		if item == e {
+			return append(entities[:i], entities[i+1:]...)
+		}
+	}
+	return entities
+}
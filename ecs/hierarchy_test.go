@@ -0,0 +1,77 @@
+package ecs
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type parentChange struct {
+	entity    *Entity
+	oldParent *Entity
+}
+
+// recordingHierarchyObserver records parent changes
+type recordingHierarchyObserver struct {
+	changes []parentChange
+}
+
+func (o *recordingHierarchyObserver) ParentChanged(entity *Entity, oldParent *Entity) {
+	o.changes = append(o.changes, parentChange{entity, oldParent})
+}
+
+func TestHierarchyCycles(t *testing.T) {
+	manager := newTestManager()
+	a, b, c := NewEntity(manager).Register(), NewEntity(manager).Register(), NewEntity(manager).Register()
+	a.AppendChild(b)
+	b.AppendChild(c)
+
+	if err := c.AppendChild(a); !errors.Is(err, ErrHierarchyCycle) {
+		t.Fatalf("expected cycle to be rejected, got %v", err)
+	}
+	if err := a.SetParent(a); !errors.Is(err, ErrHierarchyCycle) {
+		t.Fatalf("expected entity not to become its own parent, got %v", err)
+	}
+	if err := b.InsertChild(a, 0); !errors.Is(err, ErrHierarchyCycle) {
+		t.Fatalf("expected cycle to be rejected, got %v", err)
+	}
+	if a.Parent() != nil || c.Root() != a || !a.IsAncestorOf(c) {
+		t.Fatal("expected hierarchy not to change after rejected cycle")
+	}
+	if err := a.AppendChild(NewEntity(NewManager()).Register()); err == nil {
+		t.Fatal("expected child of another manager to be rejected")
+	}
+}
+
+func TestHierarchyOrderAndObservers(t *testing.T) {
+	manager := newTestManager()
+	observer := &recordingHierarchyObserver{}
+	manager.ObserveHierarchy(observer)
+	parent := NewEntity(manager).Register()
+	a, b, c := NewEntity(manager).Register(), NewEntity(manager).Register(), NewEntity(manager).Register()
+
+	parent.AppendChild(a)
+	parent.AppendChild(b)
+	parent.InsertChild(c, 0)
+	// reordering child doesn't change its parent
+	parent.InsertChild(a, 2)
+	if children := parent.Children(); !reflect.DeepEqual(children, []*Entity{c, b, a}) {
+		t.Fatalf("expected children in inserted order, got %v", children)
+	}
+	if len(observer.changes) != 3 {
+		t.Fatalf("expected 3 parent changes, got %v", observer.changes)
+	}
+
+	parent.RemoveChild(b)
+	b.AppendChild(a)
+	expected := []parentChange{{b, parent}, {a, parent}}
+	if !reflect.DeepEqual(observer.changes[3:], expected) {
+		t.Fatalf("expected observers to get previous parents, got %v", observer.changes[3:])
+	}
+	if children := parent.Children(); !reflect.DeepEqual(children, []*Entity{c}) {
+		t.Fatalf("expected moved children to be removed from parent, got %v", children)
+	}
+	if descendants := b.Descendents(); !reflect.DeepEqual(descendants, []*Entity{a}) {
+		t.Fatalf("expected moved child to be descendant of new parent, got %v", descendants)
+	}
+}
@@ -0,0 +1,128 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Index maps unique keys of component values, like GUID, to entities. Index can be shared by
+// many managers, e.g. by maps of one world, then keys are unique in all of them. Empty keys
+// aren't indexed. Safe for concurrent use.
+type Index struct {
+	lock     sync.RWMutex
+	key      func(component interface{}) string
+	entities map[string]*Entity
+	keys     map[*Entity]string
+}
+
+// NewIndex creates empty index of component values with keys returned by key
+func NewIndex(key func(component interface{}) string) *Index {
+	return &Index{
+		key:      key,
+		entities: make(map[string]*Entity),
+		keys:     make(map[*Entity]string),
+	}
+}
+
+// Lookup returns entity which component has key, entity may belong to any manager sharing
+// index or be extracted from one of them
+func (i *Index) Lookup(key string) (*Entity, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	entity, ok := i.entities[key]
+	return entity, ok
+}
+
+// RegisterIndex indexes entities by key of component, component with key used by another
+// entity isn't added. Entities which already have component are moved from previous index,
+// error is returned and nothing is changed if their keys are used in new index. Indexed
+// components must be changed with AddComponent or Add, GetMut panics for them. Can't be
+// called concurrently with Update.
+func (w *Manager) RegisterIndex(componentType reflect.Type, index *Index) error {
+	component := w.GetComponentType(componentType)
+	if component == nil {
+		panic("Indexing unregistered component " + componentType.String())
+	}
+	component.datalock.Lock()
+	defer component.datalock.Unlock()
+
+	w.entityLock.RLock()
+	defer w.entityLock.RUnlock()
+	values := make(map[*Entity]interface{}, len(component.ticks))
+	for id := range component.ticks {
+		if e, ok := w.entites[id]; ok {
+			values[e] = component.valueOf(id)
+		}
+	}
+	for e, value := range values {
+		if err := index.canSet(e, value); err != nil {
+			return err
+		}
+	}
+	for e, value := range values {
+		if component.index != nil {
+			component.index.remove(e)
+		}
+		index.set(e, value)
+	}
+	component.index = index
+	return nil
+}
+
+// Lookup returns entity of manager which component of indexed type has key
+func (w *Manager) Lookup(componentType reflect.Type, key string) (*Entity, bool) {
+	component := w.GetComponentType(componentType)
+	if component == nil || component.index == nil {
+		return nil, false
+	}
+	entity, ok := component.index.Lookup(key)
+	if !ok || entity.Manager() != w {
+		return nil, false
+	}
+	w.entityLock.RLock()
+	defer w.entityLock.RUnlock()
+	_, ok = w.entites[entity.id]
+	return entity, ok
+}
+
+// canSet returns error if key of value is already used by another entity
+func (i *Index) canSet(entity *Entity, value interface{}) error {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return i.check(entity, i.key(value))
+}
+
+func (i *Index) check(entity *Entity, key string) error {
+	if other, ok := i.entities[key]; ok && key != "" && other != entity {
+		return fmt.Errorf("key %v of entity %v is already used by entity %v", key, entity.id, other.id)
+	}
+	return nil
+}
+
+func (i *Index) set(entity *Entity, value interface{}) error {
+	key := i.key(value)
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if err := i.check(entity, key); err != nil {
+		return err
+	}
+	if old, ok := i.keys[entity]; ok {
+		delete(i.entities, old)
+		delete(i.keys, entity)
+	}
+	if key != "" {
+		i.entities[key] = entity
+		i.keys[entity] = key
+	}
+	return nil
+}
+
+func (i *Index) remove(entity *Entity) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if key, ok := i.keys[entity]; ok {
+		delete(i.entities, key)
+		delete(i.keys, entity)
+	}
+}
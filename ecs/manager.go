@@ -1,6 +1,7 @@
 package ecs
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
 	"sync"
@@ -11,20 +12,83 @@ import (
 
 const maxComponents = 64
 
+// maxFlushRounds limits how many times changes queued by observers are resolved in single flush
+const maxFlushRounds = 16
+
 // Manager ...
+//
+// Concurrency: entities can be created, registered and removed, and their components added,
+// removed and checked with HasComponent or Has from any goroutine, changes are queued and
+// applied on next Update. GetComponent, GetComponents and Get can be called from any
+// goroutine for components changed only with AddComponent or Add. Components changed in
+// place through GetMut may be accessed only by goroutine calling Update. Registration of
+// components and systems, Update, Flush and functions documented as not concurrent must
+// be called from one goroutine.
 type Manager struct {
 	commandBuffer commandBuffer
 
-	systems    systems
-	components components
-	entites    entites
+	// systemLock guards list of systems, so systems can be inspected from other goroutines
+	systemLock   sync.RWMutex
+	systems      systems
+	systemStates map[System]*systemState
+	components   components
+	entityLock   sync.RWMutex
+	entites      entites
+	registry     *Registry
+
+	hierarchyObservers []HierarchyObserver
+	relations          relations
+	// componentKeys contains components keyed by nil pointer to component type, used by
+	// generic accessors to find storage without reflection
+	componentKeys map[interface{}]*Component
+
+	resourceLock sync.RWMutex
+	resources    map[reflect.Type]interface{}
+
+	eventLock sync.RWMutex
+	events    map[reflect.Type]*Events
+
+	// tick is change tick, accessed atomically
+	tick uint64
+
+	logger Logger
+}
+
+// Logger logs messages formatted like fmt.Printf
+type Logger func(format string, args ...interface{})
+
+// SetLogger sets logger of every added and removed entity and component, nil disables
+// logging, which is default. Errors are logged with it too, they're printed to stdout
+// when logger is nil. Can't be called concurrently with Update.
+func (w *Manager) SetLogger(logger Logger) *Manager {
+	w.logger = logger
+	return w
+}
+
+func (w *Manager) logf(format string, args ...interface{}) {
+	if w.logger != nil {
+		w.logger(format, args...)
+	}
+}
+
+// Errorf logs error with logger of manager, error is printed to stdout when logger is nil
+func (w *Manager) Errorf(format string, args ...interface{}) {
+	if w.logger == nil {
+		fmt.Printf(format, args...)
+		return
+	}
+	w.logger(format, args...)
 }
 
 func NewManager() *Manager {
 	return &Manager{
-		systems:    make([]System, 0),
-		components: make(map[reflect.Type]*Component, 64),
-		entites:    make(map[uint64]*Entity, 1000),
+		systems:       make([]System, 0),
+		systemStates:  make(map[System]*systemState),
+		components:    make(map[reflect.Type]*Component, 64),
+		componentKeys: make(map[interface{}]*Component, 64),
+		entites:       make(map[uint64]*Entity, 1000),
+		resources:     make(map[reflect.Type]interface{}),
+		events:        make(map[reflect.Type]*Events),
 	}
 }
 
@@ -49,17 +113,39 @@ func (w *Manager) RegisterComponent(componentType reflect.Type) *Manager {
 		return w
 	}
 
-	if w.components.Len() > maxComponents {
+	if w.components.Len() >= maxComponents {
 		panic("Too many component types!")
 	}
 
 	newType := &Component{
-		id:       uint8(w.components.Len()),
-		datalock: &sync.RWMutex{},
-		data:     make(map[uint64]*interface{}),
+		id:          uint8(w.components.Len()),
+		reflectType: componentType,
+		tag:         componentType.Size() == 0,
+		datalock:    &sync.RWMutex{},
+		data:        make(map[uint64]interface{}),
+		ticks:       make(map[uint64]componentTicks),
 	}
 
 	w.components[componentType] = newType
+	w.componentKeys[reflect.Zero(reflect.PtrTo(componentType)).Interface()] = newType
+	return w
+}
+
+// IsTag returns true if component type is registered tag. Zero-size types, like empty
+// structs, are registered as tags, which have no data. Presence of tag is stored in entity
+// flags, its added and changed ticks are kept only for change detection.
+func (w *Manager) IsTag(componentType reflect.Type) bool {
+	component := w.GetComponentType(componentType)
+	return component != nil && component.tag
+}
+
+// Observe registers observer of added and removed components of registered type
+func (w *Manager) Observe(componentType reflect.Type, observer ComponentObserver) *Manager {
+	component := w.GetComponentType(componentType)
+	if component == nil {
+		panic("Observing unregistered component " + componentType.String())
+	}
+	component.observers = append(component.observers, observer)
 	return w
 }
 
@@ -78,7 +164,10 @@ func (w *Manager) RegisterSystem(system System) *Manager {
 		initializer.New(w)
 	}
 
+	w.systemLock.Lock()
+	defer w.systemLock.Unlock()
 	w.systems = append(w.systems, system)
+	w.systemStates[system] = &systemState{}
 	sort.Sort(w.systems)
 	return w
 }
@@ -91,9 +180,14 @@ func (w *Manager) RegisterSystems(systems ...System) *Manager {
 			initializer.New(w)
 		}
 
+		w.systemLock.Lock()
 		w.systems = append(w.systems, system)
+		w.systemStates[system] = &systemState{}
+		w.systemLock.Unlock()
 	}
+	w.systemLock.Lock()
 	sort.Sort(w.systems)
+	w.systemLock.Unlock()
 	return w
 }
 
@@ -104,12 +198,163 @@ func (w *Manager) Systems() []System {
 
 // Update updates each System managed by the World. It is invoked by the engine
 // once every frame, with dt being the duration since the previous update.
+// Change tick is advanced when changes are resolved and before each system is updated.
+// Disabled systems aren't updated.
 func (w *Manager) Update(dt float32) {
-	w.commandBuffer.resolveComponents(w.components)
-	w.commandBuffer.resolveEntites(w.entites)
+	w.updateEvents()
+	w.Flush()
+	tick := w.Tick()
+	for _, component := range w.components {
+		component.pruneRemoved(tick)
+	}
 	for _, system := range w.Systems() {
-		system.Update(dt)
+		w.nextTick()
+		w.updateSystem(system, dt)
+	}
+}
+
+// Flush resolves queued entity and component changes without updating systems. Changes
+// queued by observers are resolved too. Like Update, it can't be called concurrently with Update.
+func (w *Manager) Flush() {
+	w.nextTick()
+	for i := 0; i < maxFlushRounds; i++ {
+		if w.commandBuffer.empty() {
+			return
+		}
+		w.commandBuffer.resolve(w)
+	}
+	w.Errorf("Observers are still changing components after %v rounds, resolving next update\n", maxFlushRounds)
+}
+
+// ExtractEntity immediately removes entity with all its components from manager and returns
+// component values, so entity can be inserted into another manager. Queued changes are
+// resolved first. Parent and children of entity are kept, they should be extracted too or
+// detached before. Relations of entity are removed. Keys of entity in indexes are kept, so
+// entity keeps its identity, like GUID, until it's inserted into manager sharing indexes.
+// Can't be called concurrently with Update.
+func (w *Manager) ExtractEntity(e *Entity) []interface{} {
+	w.Flush()
+	return w.detach(e, w.nextTick(), false)
+}
+
+// destroy removes entity with its descendants and detaches it from parent
+func (w *Manager) destroy(e *Entity) {
+	for _, child := range e.Children() {
+		w.destroy(child)
+	}
+	if e.parent != nil {
+		e.parent.children = removeEntity(e.parent.children, e)
+		e.parent = nil
+	}
+	w.detach(e, w.Tick(), true)
+}
+
+// detach removes entity and its components, notifying observers and systems. Keys of
+// entity are removed from indexes if release is true.
+func (w *Manager) detach(e *Entity, tick uint64, release bool) []interface{} {
+	var values []interface{}
+	for _, component := range w.components {
+		value, ok := component.remove(e, tick, release)
+		if !ok {
+			continue
+		}
+		values = append(values, value)
+		e.clearFlag(component.id)
+		for _, observer := range component.observers {
+			observer.ComponentRemoved(e)
+		}
+	}
+
+	w.entityLock.Lock()
+	delete(w.entites, e.id)
+	w.entityLock.Unlock()
+	w.relations.removeEntity(e)
+	for _, system := range w.systems {
+		system.Remove(e)
 	}
+	return values
+}
+
+// InsertEntity immediately adds entity extracted from another manager together with its
+// components, entity keeps its id. Returns error and doesn't insert entity if its indexed
+// component has key used by another entity. Can't be called concurrently with Update.
+func (w *Manager) InsertEntity(e *Entity, components []interface{}) error {
+	for _, c := range components {
+		component := w.GetComponentType(reflect.TypeOf(c))
+		if component == nil || component.index == nil {
+			continue
+		}
+		if err := component.index.canSet(e, c); err != nil {
+			return fmt.Errorf("can't insert entity %v: %v", e.id, err)
+		}
+	}
+
+	e.manager.Store(w)
+	e.resetFlags()
+	w.entityLock.Lock()
+	w.entites[e.id] = e
+	w.entityLock.Unlock()
+
+	var added []interface{}
+	tick := w.nextTick()
+	for _, c := range components {
+		component := w.GetComponentType(reflect.TypeOf(c))
+		if component == nil {
+			w.Errorf("Dropping unregistered component %v of entity %v\n", reflect.TypeOf(c), e.id)
+			continue
+		}
+		var pointer interface{}
+		if !component.tag {
+			pointer = newPointer(c)
+		}
+		if err := component.set(e, c, pointer, tick); err != nil {
+			w.Errorf("Dropping component %v of entity %v, %v\n", reflect.TypeOf(c), e.id, err)
+			continue
+		}
+		e.setFlag(component.id)
+		added = append(added, c)
+	}
+
+	// observers are notified when all components are in place
+	for _, c := range added {
+		for _, observer := range w.GetComponentType(reflect.TypeOf(c)).observers {
+			observer.ComponentAdded(e, c)
+		}
+	}
+	return nil
+}
+
+// ComponentTypes returns all registered component types
+func (w *Manager) ComponentTypes() []reflect.Type {
+	types := make([]reflect.Type, 0, len(w.components))
+	for t := range w.components {
+		types = append(types, t)
+	}
+	return types
+}
+
+// Snapshot returns component values of all entities sorted by entity id. Queued changes
+// are resolved first. Can't be called concurrently with Update.
+func (w *Manager) Snapshot() ([]*Entity, [][]interface{}) {
+	w.Flush()
+
+	w.entityLock.RLock()
+	entities := make([]*Entity, 0, len(w.entites))
+	for _, e := range w.entites {
+		entities = append(entities, e)
+	}
+	w.entityLock.RUnlock()
+	sort.Slice(entities, func(i, j int) bool { return entities[i].id < entities[j].id })
+
+	values := make([][]interface{}, len(entities))
+	for i, e := range entities {
+		for _, component := range w.components {
+			if value, ok := component.get(e); ok {
+				values[i] = append(values[i], value)
+			}
+		}
+	}
+	return entities, values
 }
 
 // RemoveEntity removes the entity across all systems.
@@ -117,9 +362,16 @@ func (w *Manager) RemoveEntity(e *Entity) {
 	w.commandBuffer.removeEntity(e)
 }
 
-func (w *Manager) RemoveEntityWithId(id uint64) {
+// Entity returns entity with id
+func (w *Manager) Entity(id uint64) (*Entity, bool) {
+	w.entityLock.RLock()
+	defer w.entityLock.RUnlock()
 	entity, ok := w.entites[id]
-	if ok {
+	return entity, ok
+}
+
+func (w *Manager) RemoveEntityWithId(id uint64) {
+	if entity, ok := w.Entity(id); ok {
 		w.commandBuffer.removeEntity(entity)
 	}
 }
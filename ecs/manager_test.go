@@ -0,0 +1,165 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testGUID struct {
+	ID int
+}
+
+type testMovement struct {
+	Speed float32
+}
+
+var (
+	testGUIDType     = reflect.TypeOf(testGUID{})
+	testMovementType = reflect.TypeOf(testMovement{})
+)
+
+// testSystem reads components of all entities while they are changed from other goroutines
+type testSystem struct {
+	manager *Manager
+}
+
+func (s *testSystem) New(manager *Manager) { s.manager = manager }
+func (s *testSystem) Remove(e *Entity)     {}
+func (s *testSystem) Update(dt float32) {
+	s.manager.entityLock.RLock()
+	entities := make([]*Entity, 0, len(s.manager.entites))
+	for _, e := range s.manager.entites {
+		entities = append(entities, e)
+	}
+	s.manager.entityLock.RUnlock()
+	for _, e := range entities {
+		Get[testMovement](e)
+	}
+}
+
+func newTestManager() *Manager {
+	manager := NewManager()
+	manager.RegisterComponents(testGUIDType, testMovementType)
+	manager.RegisterSystem(&testSystem{})
+	return manager
+}
+
+// TestConcurrentUsage adds, reads and removes components from many goroutines while manager
+// is updated, it should be run with -race
+func TestConcurrentUsage(t *testing.T) {
+	manager := newTestManager()
+
+	const goroutines = 16
+	var wait sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			e := NewEntity(manager).
+				AddComponent(testGUID{ID: i}).
+				AddComponent(testMovement{Speed: 1}).
+				Register()
+			for e.GetComponent(testMovementType) == nil {
+				time.Sleep(time.Millisecond)
+			}
+			if guid, ok := Get[testGUID](e); !ok || guid.ID != i {
+				t.Errorf("expected guid %v, got %v", i, guid)
+			}
+			e.RemoveComponent(testMovementType)
+		}(i)
+	}
+	removed := NewEntity(manager).AddComponent(testGUID{ID: -1}).Register()
+
+	done := make(chan struct{})
+	go func() {
+		wait.Wait()
+		close(done)
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			manager.Update(0.1)
+		}
+	}
+	removed.Remove()
+	manager.Update(0.1)
+
+	entities, values := manager.Snapshot()
+	if len(entities) != goroutines {
+		t.Fatalf("expected %v entities, got %v", goroutines, len(entities))
+	}
+	for i, e := range entities {
+		if len(values[i]) != 1 || Has[testMovement](e) {
+			t.Errorf("entity %v should have only guid, got %v", e.ID(), values[i])
+		}
+	}
+}
+
+func TestAddRelationRequiresRegisteredEntities(t *testing.T) {
+	manager := newTestManager()
+	source := NewEntity(manager).Register()
+	target := NewEntity(manager).Register()
+
+	if err := manager.AddRelation("targets", source, target, ""); err == nil {
+		t.Fatal("expected relation of unresolved entities to be rejected")
+	}
+	manager.Update(0)
+	if err := manager.AddRelation("targets", source, target, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	manager.RemoveEntity(target)
+	manager.Update(0)
+	if manager.HasRelation("targets", source, target) {
+		t.Fatal("expected relation of removed entity to be removed")
+	}
+	if err := manager.AddRelation("targets", source, target, ""); err == nil {
+		t.Fatal("expected relation of removed entity to be rejected")
+	}
+}
+
+func TestErrorsLogged(t *testing.T) {
+	var logged []string
+	manager := newTestManager().SetLogger(func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	})
+	manager.RegisterIndex(testGUIDType, NewIndex(func(c interface{}) string {
+		return strconv.Itoa(c.(testGUID).ID)
+	}))
+	NewEntity(manager).AddComponent(testGUID{1}).Register()
+	NewEntity(manager).AddComponent(testGUID{1}).Register()
+	e := NewEntity(manager).Register()
+	manager.Flush()
+	e.RemoveComponent(testEventType)
+	manager.InsertEntity(NewEntity(manager), []interface{}{testEvent{}})
+
+	count := 0
+	for _, message := range logged {
+		if strings.Contains(message, "rejected") || strings.Contains(message, "unregistered") {
+			count++
+		}
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 errors to be logged, got %v", logged)
+	}
+}
+
+func TestComponentLimit(t *testing.T) {
+	manager := NewManager()
+	for i := 1; i <= maxComponents; i++ {
+		manager.RegisterComponent(reflect.ArrayOf(i, reflect.TypeOf(byte(0))))
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected component over limit to be rejected")
+		}
+	}()
+	manager.RegisterComponent(testMovementType)
+}
@@ -0,0 +1,187 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// PrefabsType is type of Prefabs resource used by Manager.Spawn
+var PrefabsType = reflect.TypeOf((*Prefabs)(nil))
+
+// Prefab is template of entity. Components are keyed by registered name and contain
+// values of its fields, prefab extending another one overrides fields of its components.
+type Prefab struct {
+	Name       string                     `json:"name"`
+	Extends    string                     `json:"extends"`
+	Components map[string]json.RawMessage `json:"components"`
+}
+
+// Prefabs contains templates of entities validated against registered component types
+type Prefabs struct {
+	components map[string][]interface{}
+}
+
+type prefabList struct {
+	Prefabs []Prefab `json:"prefabs"`
+}
+
+// LoadPrefabs parses list of prefabs from json. Returns error if prefab uses component which
+// isn't in registry, unknown component fields, or extends missing prefab.
+func LoadPrefabs(data []byte, registry *Registry) (*Prefabs, error) {
+	var list prefabList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]reflect.Type)
+	for _, info := range registry.Components() {
+		types[info.Name] = info.Type
+	}
+	byName := make(map[string]*Prefab, len(list.Prefabs))
+	for i := range list.Prefabs {
+		prefab := &list.Prefabs[i]
+		if prefab.Name == "" {
+			return nil, fmt.Errorf("prefab %v has no name", i)
+		}
+		if _, ok := byName[prefab.Name]; ok {
+			return nil, fmt.Errorf("prefab %v defined twice", prefab.Name)
+		}
+		byName[prefab.Name] = prefab
+	}
+
+	prefabs := &Prefabs{components: make(map[string][]interface{}, len(list.Prefabs))}
+	for _, prefab := range list.Prefabs {
+		values, err := resolvePrefab(prefab.Name, byName, types)
+		if err != nil {
+			return nil, err
+		}
+		prefabs.components[prefab.Name] = values
+	}
+	return prefabs, nil
+}
+
+// resolvePrefab decodes components of prefab and its ancestors, starting from the root
+func resolvePrefab(name string, byName map[string]*Prefab, types map[string]reflect.Type) ([]interface{}, error) {
+	var chain []*Prefab
+	visited := make(map[string]bool)
+	for current := name; current != ""; {
+		prefab, ok := byName[current]
+		if !ok {
+			return nil, fmt.Errorf("prefab %v extends unknown prefab %v", chain[0].Name, current)
+		}
+		if visited[current] {
+			return nil, fmt.Errorf("prefab %v has inheritance cycle", name)
+		}
+		visited[current] = true
+		chain = append([]*Prefab{prefab}, chain...)
+		current = prefab.Extends
+	}
+
+	values := make(map[reflect.Type]reflect.Value)
+	var order []string
+	for _, prefab := range chain {
+		// sorted, so values are created in the same order on every load
+		names := make([]string, 0, len(prefab.Components))
+		for componentName := range prefab.Components {
+			names = append(names, componentName)
+		}
+		sort.Strings(names)
+
+		for _, componentName := range names {
+			t, ok := types[componentName]
+			if !ok {
+				return nil, fmt.Errorf("prefab %v uses unregistered component %v", prefab.Name, componentName)
+			}
+			value, ok := values[t]
+			if !ok {
+				value = reflect.New(t)
+				values[t] = value
+				order = append(order, componentName)
+			}
+			// decoding into value of parent overrides only fields present in child
+			decoder := json.NewDecoder(bytes.NewReader(prefab.Components[componentName]))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(value.Interface()); err != nil {
+				return nil, fmt.Errorf("prefab %v has invalid component %v: %v", prefab.Name, componentName, err)
+			}
+		}
+	}
+
+	result := make([]interface{}, len(order))
+	for i, componentName := range order {
+		result[i] = values[types[componentName]].Elem().Interface()
+	}
+	return result, nil
+}
+
+// Names returns names of all prefabs sorted alphabetically
+func (p *Prefabs) Names() []string {
+	names := make([]string, 0, len(p.components))
+	for name := range p.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Components returns component values of prefab
+func (p *Prefabs) Components(name string) ([]interface{}, bool) {
+	values, ok := p.components[name]
+	if !ok {
+		return nil, false
+	}
+	return append([]interface{}(nil), values...), true
+}
+
+// Spawn creates and registers entity from prefab stored in Prefabs resource. Overrides are
+// component values replacing components of prefab or added to them. Indexed components, like
+// GUID, are unique, so they can be only passed as overrides and not come from prefab.
+func (w *Manager) Spawn(name string, overrides ...interface{}) (*Entity, error) {
+	prefabs, ok := Resource[*Prefabs](w)
+	if !ok {
+		return nil, fmt.Errorf("can't spawn %v, manager has no prefabs", name)
+	}
+	components, ok := prefabs.Components(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown prefab %v", name)
+	}
+
+	for _, c := range components {
+		component := w.GetComponentType(reflect.TypeOf(c))
+		if component != nil && component.index != nil && !containsValueOf(overrides, c) {
+			return nil, fmt.Errorf("can't spawn %v, unique component %v must be passed as override", name, reflect.TypeOf(c))
+		}
+	}
+	for _, override := range overrides {
+		replaced := false
+		for i, c := range components {
+			if reflect.TypeOf(c) == reflect.TypeOf(override) {
+				components[i] = override
+				replaced = true
+			}
+		}
+		if !replaced {
+			components = append(components, override)
+		}
+	}
+
+	for _, c := range components {
+		if w.GetComponentType(reflect.TypeOf(c)) == nil {
+			return nil, fmt.Errorf("can't spawn %v, component %v isn't registered", name, reflect.TypeOf(c))
+		}
+	}
+	return NewEntity(w).AddComponents(components...).Register(), nil
+}
+
+// containsValueOf returns true if values contain value of the same type as value
+func containsValueOf(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.TypeOf(v) == reflect.TypeOf(value) {
+			return true
+		}
+	}
+	return false
+}
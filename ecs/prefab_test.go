@@ -0,0 +1,115 @@
+package ecs
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testBlock struct {
+	SizeX, SizeY, SizeZ int
+}
+
+func newPrefabRegistry() *Registry {
+	return NewRegistry().
+		MustRegister("guid", 1, testGUIDType).
+		MustRegister("movement", 2, testMovementType).
+		MustRegister("block", 3, reflect.TypeOf(testBlock{}))
+}
+
+const testPrefabs = `{"prefabs":[
+	{"name":"creature","components":{"block":{"SizeX":1,"SizeY":1,"SizeZ":2},"movement":{"Speed":1}}},
+	{"name":"goblin","extends":"creature","components":{"block":{"SizeZ":1}}},
+	{"name":"troll","extends":"goblin","components":{"block":{"SizeX":2},"movement":{"Speed":0.5}}}
+]}`
+
+func TestPrefabInheritance(t *testing.T) {
+	prefabs, err := LoadPrefabs([]byte(testPrefabs), newPrefabRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]interface{}{
+		"creature": {testBlock{1, 1, 2}, testMovement{1}},
+		"goblin":   {testBlock{1, 1, 1}, testMovement{1}},
+		"troll":    {testBlock{2, 1, 1}, testMovement{0.5}},
+	}
+	for name, values := range expected {
+		components, ok := prefabs.Components(name)
+		if !ok || !reflect.DeepEqual(components, values) {
+			t.Errorf("expected prefab %v to have %v, got %v", name, values, components)
+		}
+	}
+}
+
+func TestInvalidPrefabs(t *testing.T) {
+	invalid := map[string]string{
+		"cycle":     `{"prefabs":[{"name":"a","extends":"b"},{"name":"b","extends":"c"},{"name":"c","extends":"a"}]}`,
+		"self":      `{"prefabs":[{"name":"a","extends":"a"}]}`,
+		"unknown":   `{"prefabs":[{"name":"a","extends":"b"}]}`,
+		"duplicate": `{"prefabs":[{"name":"a"},{"name":"a"}]}`,
+		"component": `{"prefabs":[{"name":"a","components":{"health":{}}}]}`,
+		"field":     `{"prefabs":[{"name":"a","components":{"movement":{"Sped":1}}}]}`,
+		"unnamed":   `{"prefabs":[{"components":{}}]}`,
+	}
+	for name, data := range invalid {
+		if _, err := LoadPrefabs([]byte(data), newPrefabRegistry()); err == nil {
+			t.Errorf("expected %v prefabs to be rejected", name)
+		}
+	}
+}
+
+func TestSpawnPrefab(t *testing.T) {
+	prefabs, err := LoadPrefabs([]byte(testPrefabs), newPrefabRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := newTestManager().AddResource(prefabs)
+	manager.RegisterComponent(reflect.TypeOf(testBlock{}))
+
+	e, err := manager.Spawn("troll", testMovement{2}, testGUID{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.Flush()
+	if block, _ := Get[testBlock](e); block != (testBlock{2, 1, 1}) {
+		t.Fatalf("expected block of prefab, got %v", block)
+	}
+	if movement, _ := Get[testMovement](e); movement.Speed != 2 {
+		t.Fatalf("expected overridden movement, got %v", movement)
+	}
+	if guid, ok := Get[testGUID](e); !ok || guid.ID != 1 {
+		t.Fatalf("expected override to be added, got %v", guid)
+	}
+	if _, err := manager.Spawn("dragon"); err == nil {
+		t.Fatal("expected unknown prefab to be rejected")
+	}
+}
+
+func TestSpawnRejectsUniqueComponentsOfPrefab(t *testing.T) {
+	prefabs, err := LoadPrefabs([]byte(`{"prefabs":[{"name":"unique","components":{"guid":{"ID":1}}}]}`), newPrefabRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := newTestManager().AddResource(prefabs)
+	if err := manager.RegisterIndex(testGUIDType, NewIndex(func(c interface{}) string {
+		return strconv.Itoa(c.(testGUID).ID)
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := manager.Spawn("unique"); err == nil || !strings.Contains(err.Error(), "unique") {
+		t.Fatalf("expected indexed component of prefab to be rejected, got %v", err)
+	}
+	for id := 2; id < 4; id++ {
+		if _, err := manager.Spawn("unique", testGUID{id}); err != nil {
+			t.Fatalf("expected overridden unique component to be accepted, got %v", err)
+		}
+	}
+	manager.Flush()
+	for id := 2; id < 4; id++ {
+		if _, ok := manager.Lookup(testGUIDType, strconv.Itoa(id)); !ok {
+			t.Fatalf("expected spawned entity %v to be indexed", id)
+		}
+	}
+}
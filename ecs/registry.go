@@ -0,0 +1,159 @@
+package ecs
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ComponentInfo describes component type registered with stable name and wire id, which
+// don't change between builds and can be used in saved data, templates and network messages
+type ComponentInfo struct {
+	Name   string       `json:"name"`
+	WireID uint16       `json:"wireId"`
+	Type   reflect.Type `json:"-"`
+}
+
+// MarshalJSON adds go type name for tooling
+func (info ComponentInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name   string `json:"name"`
+		WireID uint16 `json:"wireId"`
+		Type   string `json:"type"`
+	}{info.Name, info.WireID, info.Type.String()})
+}
+
+// Registry maps component types to stable names and wire ids. Registry is usually
+// shared by all managers. Safe for concurrent use.
+type Registry struct {
+	lock   sync.RWMutex
+	byName map[string]ComponentInfo
+	byWire map[uint16]ComponentInfo
+	byType map[reflect.Type]ComponentInfo
+}
+
+// NewRegistry creates empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		byName: make(map[string]ComponentInfo),
+		byWire: make(map[uint16]ComponentInfo),
+		byType: make(map[reflect.Type]ComponentInfo),
+	}
+}
+
+// Register adds component type, name, wire id and type must be unique
+func (r *Registry) Register(name string, wireID uint16, componentType reflect.Type) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if name == "" {
+		return fmt.Errorf("component %v has no name", componentType)
+	}
+	if info, ok := r.byName[name]; ok {
+		return fmt.Errorf("component name %v already used by %v", name, info.Type)
+	}
+	if info, ok := r.byWire[wireID]; ok {
+		return fmt.Errorf("component wire id %v already used by %v", wireID, info.Name)
+	}
+	if info, ok := r.byType[componentType]; ok {
+		return fmt.Errorf("component %v already registered as %v", componentType, info.Name)
+	}
+
+	info := ComponentInfo{Name: name, WireID: wireID, Type: componentType}
+	r.byName[name] = info
+	r.byWire[wireID] = info
+	r.byType[componentType] = info
+	return nil
+}
+
+// MustRegister adds component type and panics if it can't be registered
+func (r *Registry) MustRegister(name string, wireID uint16, componentType reflect.Type) *Registry {
+	if err := r.Register(name, wireID, componentType); err != nil {
+		panic(err.Error())
+	}
+	return r
+}
+
+// ByName returns component registered with name
+func (r *Registry) ByName(name string) (ComponentInfo, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	info, ok := r.byName[name]
+	return info, ok
+}
+
+// ByWireID returns component registered with wire id
+func (r *Registry) ByWireID(wireID uint16) (ComponentInfo, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	info, ok := r.byWire[wireID]
+	return info, ok
+}
+
+// ByType returns registered component of given type
+func (r *Registry) ByType(componentType reflect.Type) (ComponentInfo, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	info, ok := r.byType[componentType]
+	return info, ok
+}
+
+// Components returns all registered components sorted by wire id
+func (r *Registry) Components() []ComponentInfo {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	result := make([]ComponentInfo, 0, len(r.byWire))
+	for _, info := range r.byWire {
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].WireID < result[j].WireID })
+	return result
+}
+
+// Dump returns all registered components as json
+func (r *Registry) Dump() ([]byte, error) {
+	return json.MarshalIndent(r.Components(), "", "    ")
+}
+
+// RegisterRegistry registers all components of registry, registry is then used to name components
+func (w *Manager) RegisterRegistry(registry *Registry) *Manager {
+	w.registry = registry
+	for _, info := range registry.Components() {
+		w.RegisterComponent(info.Type)
+	}
+	return w
+}
+
+// Registry returns registry of manager or nil if components are registered without names
+func (w *Manager) Registry() *Registry {
+	return w.registry
+}
+
+// ComponentName returns stable name of component type, types missing in registry are
+// named by go type
+func (w *Manager) ComponentName(componentType reflect.Type) string {
+	if w.registry != nil {
+		if info, ok := w.registry.ByType(componentType); ok {
+			return info.Name
+		}
+	}
+	return componentType.String()
+}
+
+// ComponentByName returns registered component type with name returned by ComponentName
+func (w *Manager) ComponentByName(name string) (reflect.Type, bool) {
+	if w.registry != nil {
+		if info, ok := w.registry.ByName(name); ok && w.GetComponentType(info.Type) != nil {
+			return info.Type, true
+		}
+	}
+	for t := range w.components {
+		if t.String() == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,208 @@
+package ecs
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RelationKind names relation between two entities, like "targets" or "ownedBy"
+type RelationKind string
+
+// Relation connects source entity with target entity. Data can describe relation, like
+// slot of equipped item.
+type Relation struct {
+	Kind   RelationKind
+	Source *Entity
+	Target *Entity
+	Data   string
+}
+
+// relationKind contains relations of single kind indexed in both directions
+type relationKind struct {
+	exclusive bool
+	bySource  map[*Entity]map[*Entity]Relation
+	byTarget  map[*Entity]map[*Entity]Relation
+}
+
+// relations are removed when either entity is removed from manager. Safe for concurrent use.
+type relations struct {
+	lock  sync.RWMutex
+	kinds map[RelationKind]*relationKind
+}
+
+// RegisterRelation registers relation kind, source of exclusive relation can have only one
+// target, adding another target replaces previous one. Unregistered kinds aren't exclusive.
+func (w *Manager) RegisterRelation(kind RelationKind, exclusive bool) *Manager {
+	w.relations.lock.Lock()
+	defer w.relations.lock.Unlock()
+	w.relations.kind(kind).exclusive = exclusive
+	return w
+}
+
+func (r *relations) kind(kind RelationKind) *relationKind {
+	if r.kinds == nil {
+		r.kinds = make(map[RelationKind]*relationKind)
+	}
+	k, ok := r.kinds[kind]
+	if !ok {
+		k = &relationKind{
+			bySource: make(map[*Entity]map[*Entity]Relation),
+			byTarget: make(map[*Entity]map[*Entity]Relation),
+		}
+		r.kinds[kind] = k
+	}
+	return k
+}
+
+// AddRelation relates source to target, replacing data of existing relation. Both entities
+// must be registered in manager, so relation is added after entities created in the same
+// tick are resolved. Returns error if either of them isn't registered or was removed.
+func (w *Manager) AddRelation(kind RelationKind, source *Entity, target *Entity, data string) error {
+	for _, e := range []*Entity{source, target} {
+		if registered, ok := w.Entity(e.id); !ok || registered != e {
+			return fmt.Errorf("can't add %v relation, entity %v isn't registered", kind, e.id)
+		}
+	}
+	w.relations.lock.Lock()
+	defer w.relations.lock.Unlock()
+
+	k := w.relations.kind(kind)
+	if k.exclusive {
+		for old := range k.bySource[source] {
+			if old != target {
+				k.remove(source, old)
+			}
+		}
+	}
+	relation := Relation{Kind: kind, Source: source, Target: target, Data: data}
+	if k.bySource[source] == nil {
+		k.bySource[source] = make(map[*Entity]Relation)
+	}
+	if k.byTarget[target] == nil {
+		k.byTarget[target] = make(map[*Entity]Relation)
+	}
+	k.bySource[source][target] = relation
+	k.byTarget[target][source] = relation
+	return nil
+}
+
+// RemoveRelation removes relation between source and target
+func (w *Manager) RemoveRelation(kind RelationKind, source *Entity, target *Entity) {
+	w.relations.lock.Lock()
+	defer w.relations.lock.Unlock()
+	if k, ok := w.relations.kinds[kind]; ok {
+		k.remove(source, target)
+	}
+}
+
+func (k *relationKind) remove(source *Entity, target *Entity) {
+	delete(k.bySource[source], target)
+	if len(k.bySource[source]) == 0 {
+		delete(k.bySource, source)
+	}
+	delete(k.byTarget[target], source)
+	if len(k.byTarget[target]) == 0 {
+		delete(k.byTarget, target)
+	}
+}
+
+// Relation returns relation between source and target
+func (w *Manager) Relation(kind RelationKind, source *Entity, target *Entity) (Relation, bool) {
+	w.relations.lock.RLock()
+	defer w.relations.lock.RUnlock()
+	if k, ok := w.relations.kinds[kind]; ok {
+		relation, ok := k.bySource[source][target]
+		return relation, ok
+	}
+	return Relation{}, false
+}
+
+// HasRelation returns true if source is related to target
+func (w *Manager) HasRelation(kind RelationKind, source *Entity, target *Entity) bool {
+	_, ok := w.Relation(kind, source, target)
+	return ok
+}
+
+// RelationsFrom returns relations of kind in which entity is source, sorted by target id
+func (w *Manager) RelationsFrom(kind RelationKind, source *Entity) []Relation {
+	w.relations.lock.RLock()
+	defer w.relations.lock.RUnlock()
+	if k, ok := w.relations.kinds[kind]; ok {
+		return sortedRelations(k.bySource[source], func(r Relation) uint64 { return r.Target.id })
+	}
+	return nil
+}
+
+// RelationsTo returns relations of kind in which entity is target, sorted by source id
+func (w *Manager) RelationsTo(kind RelationKind, target *Entity) []Relation {
+	w.relations.lock.RLock()
+	defer w.relations.lock.RUnlock()
+	if k, ok := w.relations.kinds[kind]; ok {
+		return sortedRelations(k.byTarget[target], func(r Relation) uint64 { return r.Source.id })
+	}
+	return nil
+}
+
+// Targets returns entities which source is related to, e.g. entities targeted by source
+func (w *Manager) Targets(kind RelationKind, source *Entity) []*Entity {
+	relations := w.RelationsFrom(kind, source)
+	result := make([]*Entity, len(relations))
+	for i, r := range relations {
+		result[i] = r.Target
+	}
+	return result
+}
+
+// Sources returns entities related to target, e.g. all entities which target it
+func (w *Manager) Sources(kind RelationKind, target *Entity) []*Entity {
+	relations := w.RelationsTo(kind, target)
+	result := make([]*Entity, len(relations))
+	for i, r := range relations {
+		result[i] = r.Source
+	}
+	return result
+}
+
+// AllRelations returns all relations of entity in which it's source, sorted by kind and target id
+func (w *Manager) AllRelations(source *Entity) []Relation {
+	w.relations.lock.RLock()
+	var result []Relation
+	for _, k := range w.relations.kinds {
+		for _, r := range k.bySource[source] {
+			result = append(result, r)
+		}
+	}
+	w.relations.lock.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		return result[i].Target.id < result[j].Target.id
+	})
+	return result
+}
+
+// removeEntity removes all relations of entity
+func (r *relations) removeEntity(e *Entity) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, k := range r.kinds {
+		for target := range k.bySource[e] {
+			k.remove(e, target)
+		}
+		for source := range k.byTarget[e] {
+			k.remove(source, e)
+		}
+	}
+}
+
+func sortedRelations(relations map[*Entity]Relation, key func(Relation) uint64) []Relation {
+	result := make([]Relation, 0, len(relations))
+	for _, r := range relations {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool { return key(result[i]) < key(result[j]) })
+	return result
+}
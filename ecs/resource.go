@@ -0,0 +1,120 @@
+package ecs
+
+import (
+	"reflect"
+)
+
+// Resources are values shared by all systems of manager, like map, tile atlas or clock.
+// Each resource is identified by its type, interfaces can be registered with SetResource
+// so systems don't depend on concrete types.
+
+// AddResource registers resource under its own type, replacing previous resource of that type
+func (w *Manager) AddResource(resource interface{}) *Manager {
+	return w.SetResource(reflect.TypeOf(resource), resource)
+}
+
+// SetResource registers resource under given type, which is usually interface implemented by resource
+func (w *Manager) SetResource(resourceType reflect.Type, resource interface{}) *Manager {
+	if resourceType.Kind() == reflect.Interface {
+		if !reflect.TypeOf(resource).Implements(resourceType) {
+			panic("Resource " + reflect.TypeOf(resource).String() + " doesn't implement " + resourceType.String())
+		}
+	} else if reflect.TypeOf(resource) != resourceType {
+		panic("Resource " + reflect.TypeOf(resource).String() + " isn't " + resourceType.String())
+	}
+
+	w.resourceLock.Lock()
+	defer w.resourceLock.Unlock()
+	if w.resources == nil {
+		w.resources = make(map[reflect.Type]interface{})
+	}
+	w.resources[resourceType] = resource
+	return w
+}
+
+// RemoveResource removes resource of given type
+func (w *Manager) RemoveResource(resourceType reflect.Type) *Manager {
+	w.resourceLock.Lock()
+	defer w.resourceLock.Unlock()
+	delete(w.resources, resourceType)
+	return w
+}
+
+// Resource returns resource of given type or nil if it's not registered
+func (w *Manager) Resource(resourceType reflect.Type) interface{} {
+	w.resourceLock.RLock()
+	defer w.resourceLock.RUnlock()
+	return w.resources[resourceType]
+}
+
+// Resource returns resource registered under type T, which is resource's own type or
+// interface it was set as
+func Resource[T any](w *Manager) (T, bool) {
+	resource, ok := w.Resource(reflect.TypeOf((*T)(nil)).Elem()).(T)
+	return resource, ok
+}
+
+// HasResource returns true if resource of given type is registered
+func (w *Manager) HasResource(resourceType reflect.Type) bool {
+	return w.Resource(resourceType) != nil
+}
+
+// Access lists component and resource types which system reads and writes during Update
+type Access struct {
+	Read  []reflect.Type
+	Write []reflect.Type
+}
+
+// AccessDeclarer is implemented by systems declaring which types they access. Systems
+// which don't declare access are treated as exclusive, conflicting with all other systems.
+// Declarations allow running systems which don't conflict in parallel.
+type AccessDeclarer interface {
+	Access() Access
+}
+
+// Reads returns true if type is read or written
+func (a Access) Reads(t reflect.Type) bool {
+	return containsType(a.Read, t) || a.Writes(t)
+}
+
+// Writes returns true if type is written
+func (a Access) Writes(t reflect.Type) bool {
+	return containsType(a.Write, t)
+}
+
+// Conflicts returns true if one access writes type which other reads or writes
+func (a Access) Conflicts(other Access) bool {
+	for _, t := range a.Write {
+		if other.Reads(t) {
+			return true
+		}
+	}
+	for _, t := range other.Write {
+		if a.Reads(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemsConflict returns true if systems can't run at the same time
+func SystemsConflict(a System, b System) bool {
+	accessA, ok := a.(AccessDeclarer)
+	if !ok {
+		return true
+	}
+	accessB, ok := b.(AccessDeclarer)
+	if !ok {
+		return true
+	}
+	return accessA.Access().Conflicts(accessB.Access())
+}
+
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, item := range types {
+		if item == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package ecs
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testResource struct{ name string }
+
+type testNamed interface{ Name() string }
+
+func (r *testResource) Name() string { return r.name }
+
+var testNamedType = reflect.TypeOf((*testNamed)(nil)).Elem()
+
+type accessSystem struct{ access Access }
+
+func (s accessSystem) Update(dt float32) {}
+func (s accessSystem) Remove(e *Entity)  {}
+func (s accessSystem) Access() Access    { return s.access }
+
+type exclusiveSystem struct{}
+
+func (s exclusiveSystem) Update(dt float32) {}
+func (s exclusiveSystem) Remove(e *Entity)  {}
+
+func TestTypedResource(t *testing.T) {
+	manager := NewManager()
+	if _, ok := Resource[*testResource](manager); ok {
+		t.Fatal("expected missing resource not to be found")
+	}
+
+	resource := &testResource{name: "atlas"}
+	manager.AddResource(resource).SetResource(testNamedType, resource)
+	if found, ok := Resource[*testResource](manager); !ok || found != resource {
+		t.Fatalf("expected resource to be found by its type, got %v", found)
+	}
+	if named, ok := Resource[testNamed](manager); !ok || named.Name() != "atlas" {
+		t.Fatalf("expected resource to be found by interface, got %v", named)
+	}
+
+	manager.RemoveResource(testNamedType)
+	if _, ok := Resource[testNamed](manager); ok {
+		t.Fatal("expected removed resource not to be found")
+	}
+}
+
+func TestAccessConflicts(t *testing.T) {
+	a, b := testMovementType, testGUIDType
+	cases := []struct {
+		name      string
+		first     Access
+		second    Access
+		conflicts bool
+	}{
+		{"both read", Access{Read: []reflect.Type{a}}, Access{Read: []reflect.Type{a}}, false},
+		{"write and read", Access{Write: []reflect.Type{a}}, Access{Read: []reflect.Type{a}}, true},
+		{"read and write", Access{Read: []reflect.Type{a}}, Access{Write: []reflect.Type{a}}, true},
+		{"both write", Access{Write: []reflect.Type{a}}, Access{Write: []reflect.Type{a}}, true},
+		{"different types", Access{Write: []reflect.Type{a}}, Access{Write: []reflect.Type{b}}, false},
+		{"resource", Access{Write: []reflect.Type{testNamedType}}, Access{Read: []reflect.Type{b, testNamedType}}, true},
+		{"empty", Access{}, Access{Write: []reflect.Type{a}}, false},
+	}
+	for _, c := range cases {
+		if conflicts := c.first.Conflicts(c.second); conflicts != c.conflicts {
+			t.Errorf("%v: expected conflict %v, got %v", c.name, c.conflicts, conflicts)
+		}
+		if conflicts := SystemsConflict(accessSystem{c.first}, accessSystem{c.second}); conflicts != c.conflicts {
+			t.Errorf("%v: expected systems conflict %v, got %v", c.name, c.conflicts, conflicts)
+		}
+	}
+
+	if !SystemsConflict(exclusiveSystem{}, accessSystem{}) || !SystemsConflict(accessSystem{}, exclusiveSystem{}) {
+		t.Fatal("expected system without declared access to conflict with all systems")
+	}
+}
@@ -1,5 +1,12 @@
 package ecs
 
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
 // A System implements logic for processing entities possessing components of
 // the same aspects as the system. A System should iterate over its Entities on
 // `Update`, in any way suitable for the current implementation.
@@ -38,18 +45,86 @@ func (s systems) Len() int {
 }
 
 func (s systems) Less(i, j int) bool {
-	var prio1, prio2 int
+	return priorityOf(s[i]) > priorityOf(s[j])
+}
+
+func (s systems) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// SystemInfo describes registered system, it's used for debugging
+type SystemInfo struct {
+	Name     string        `json:"name"`
+	Priority int           `json:"priority"`
+	Enabled  bool          `json:"enabled"`
+	LastTick time.Duration `json:"lastTick"`
+}
+
+// systemState contains runtime state of system, it's accessed atomically
+type systemState struct {
+	disabled int32
+	lastTick int64
+}
 
-	if prior1, ok := s[i].(Prioritizer); ok {
-		prio1 = prior1.Priority()
+// SystemName returns name of system type, like *system.MovementSystem
+func SystemName(system System) string {
+	return reflect.TypeOf(system).String()
+}
+
+func priorityOf(system System) int {
+	if prioritizer, ok := system.(Prioritizer); ok {
+		return prioritizer.Priority()
 	}
-	if prior2, ok := s[j].(Prioritizer); ok {
-		prio2 = prior2.Priority()
+	return 0
+}
+
+// SystemInfos returns registered systems in order they are updated. Safe for concurrent use.
+func (w *Manager) SystemInfos() []SystemInfo {
+	w.systemLock.RLock()
+	defer w.systemLock.RUnlock()
+	result := make([]SystemInfo, len(w.systems))
+	for i, system := range w.systems {
+		state := w.systemStates[system]
+		result[i] = SystemInfo{
+			Name:     SystemName(system),
+			Priority: priorityOf(system),
+			Enabled:  atomic.LoadInt32(&state.disabled) == 0,
+			LastTick: time.Duration(atomic.LoadInt64(&state.lastTick)),
+		}
 	}
+	return result
+}
 
-	return prio1 > prio2
+// EnableSystem enables or disables updating of systems with name. Disabled systems still
+// observe components and are notified about removed entities. Safe for concurrent use.
+func (w *Manager) EnableSystem(name string, enabled bool) error {
+	w.systemLock.RLock()
+	defer w.systemLock.RUnlock()
+	found := false
+	for _, system := range w.systems {
+		if SystemName(system) != name {
+			continue
+		}
+		var disabled int32
+		if !enabled {
+			disabled = 1
+		}
+		atomic.StoreInt32(&w.systemStates[system].disabled, disabled)
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("system %v isn't registered", name)
+	}
+	return nil
 }
 
-func (s systems) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
+// updateSystem updates system if it's enabled and measures duration of update
+func (w *Manager) updateSystem(system System, dt float32) {
+	state := w.systemStates[system]
+	if atomic.LoadInt32(&state.disabled) != 0 {
+		return
+	}
+	start := time.Now()
+	system.Update(dt)
+	atomic.StoreInt64(&state.lastTick, int64(time.Since(start)))
 }
@@ -1,19 +1,42 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/Tomislaw/far-worlds/component"
 	"github.com/Tomislaw/far-worlds/ecs"
 	"github.com/Tomislaw/far-worlds/system"
+	"github.com/Tomislaw/far-worlds/world"
 	"github.com/Tomislaw/far-worlds/world/tile"
 )
 
 func main() {
+	dumpComponents := flag.Bool("components", false, "print registered components as json and exit")
+	inspect := flag.String("inspect", "", "run sample world and serve ecs inspector on address, e.g. localhost:6060")
+	flag.Parse()
+	if *dumpComponents {
+		data, err := component.Registry.Dump()
+		if err != nil {
+			panic(err.Error())
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if *inspect != "" {
+		serveInspector(*inspect)
+		return
+	}
+
 	fmt.Println(tile.Atlas.String())
 
-	manager := ecs.NewManager()
+	manager := ecs.NewManager().SetLogger(func(format string, args ...interface{}) {
+		fmt.Printf(format, args...)
+	})
 	component.RegisterComponents(manager)
 	system.RegisterSystems(manager)
 
@@ -59,3 +82,18 @@ func add(manager *ecs.Manager) {
 		AddComponent(component.MapItemMovement{}).
 		Register()
 }
+
+func serveInspector(addr string) {
+	w := world.NewWorld(world.Config{})
+	m, err := w.CreateMap(1, 4, 4)
+	if err != nil {
+		panic(err.Error())
+	}
+	add(m.Manager())
+	w.Start(context.Background())
+
+	fmt.Printf("Serving inspector on http://%v/maps\n", addr)
+	if err := http.ListenAndServe(addr, world.NewInspector(w)); err != nil {
+		panic(err.Error())
+	}
+}
@@ -0,0 +1,38 @@
+package system
+
+import (
+	"reflect"
+
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+// EntityArrived is emitted when entity with MapItemMovement reaches its target
+type EntityArrived struct {
+	Entity   *ecs.Entity
+	Position coord.Position
+}
+
+// DamageDealt is emitted when entity is damaged, source may be nil
+type DamageDealt struct {
+	Source *ecs.Entity
+	Target *ecs.Entity
+	Amount int
+}
+
+type EventTypeDefinitions struct {
+	EntityArrived reflect.Type
+	DamageDealt   reflect.Type
+}
+
+var EventType = EventTypeDefinitions{
+	EntityArrived: reflect.TypeOf((*EntityArrived)(nil)).Elem(),
+	DamageDealt:   reflect.TypeOf((*DamageDealt)(nil)).Elem(),
+}
+
+func RegisterEvents(manager *ecs.Manager) {
+	manager.RegisterEvents(
+		EventType.EntityArrived,
+		EventType.DamageDealt,
+	)
+}
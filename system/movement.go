@@ -1,17 +1,118 @@
 package system
 
-import "github.com/Tomislaw/far-worlds/ecs"
+import (
+	"reflect"
+	"sort"
 
+	"github.com/Tomislaw/far-worlds/component"
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+// Navigator checks if entity can move between tiles, e.g. if destination isn't
+// blocked by tiles or footprints of other entities
+type Navigator interface {
+	// Move returns true if entity can move and reserves destination, so other entities
+	// can't move there in the same tick
+	Move(entity *ecs.Entity, from coord.Position, to coord.Position) bool
+}
+
+// NavigatorType is type of Navigator resource
+var NavigatorType = reflect.TypeOf((*Navigator)(nil)).Elem()
+
+// MovementSystem moves entities with MapItemMovement tile by tile towards their target and
+// emits EntityArrived when they reach it. Movement is checked by Navigator resource, if
+// manager has one.
 type MovementSystem struct {
+	manager *ecs.Manager
 	entites map[uint64]*ecs.Entity
 }
 
 func (s *MovementSystem) Priority() int { return 500 }
 
+func (s *MovementSystem) Access() ecs.Access {
+	return ecs.Access{
+		// navigator is written, moves reserve destinations
+		Write: []reflect.Type{NavigatorType, component.Type.MapItem, component.Type.MapItemMovement, EventType.EntityArrived},
+	}
+}
+
+func (s *MovementSystem) New(manager *ecs.Manager) {
+	s.manager = manager
+	s.entites = make(map[uint64]*ecs.Entity)
+	manager.Observe(component.Type.MapItemMovement, s)
+}
+
+func (s *MovementSystem) ComponentAdded(entity *ecs.Entity, c interface{}) {
+	s.entites[entity.ID()] = entity
+}
+
+func (s *MovementSystem) ComponentRemoved(entity *ecs.Entity) {
+	s.Remove(entity)
+}
+
 func (s *MovementSystem) Remove(entity *ecs.Entity) {
-	s.entites[entity.ID()] = nil
+	delete(s.entites, entity.ID())
 }
 
 func (s *MovementSystem) Update(dt float32) {
+	navigator, _ := ecs.Resource[Navigator](s.manager)
+	// entities are moved in id order, so the same entity wins contested tile every tick
+	entities := make([]*ecs.Entity, 0, len(s.entites))
+	for _, entity := range s.entites {
+		entities = append(entities, entity)
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].ID() < entities[j].ID() })
+	for _, entity := range entities {
+		item, ok := ecs.Get[component.MapItem](entity)
+		if !ok || (entity.Parent() != nil && ecs.Has[component.Attached](entity)) {
+			continue
+		}
+		movement := ecs.GetMut[component.MapItemMovement](entity)
+		if movement == nil {
+			continue
+		}
+		if item.Position == movement.Target {
+			entity.RemoveComponent(component.Type.MapItemMovement)
+			s.manager.Emit(EntityArrived{Entity: entity, Position: item.Position})
+			continue
+		}
+
+		speed := movement.Speed
+		if speed == 0 {
+			speed = 1
+		}
+		movement.Progress += speed * dt
+		if movement.Progress < 1 {
+			continue
+		}
+		movement.Progress--
+
+		next := item.Position.Add(coord.Position{
+			X: sign(movement.Target.X - item.Position.X),
+			Y: sign(movement.Target.Y - item.Position.Y),
+			Z: sign(movement.Target.Z - item.Position.Z),
+		})
+		if navigator != nil && !navigator.Move(entity, item.Position, next) {
+			// wait until tile is free
+			movement.Progress = 0
+			continue
+		}
+
+		ecs.GetMut[component.MapItem](entity).Position = next
+		if next == movement.Target {
+			entity.RemoveComponent(component.Type.MapItemMovement)
+			s.manager.Emit(EntityArrived{Entity: entity, Position: next})
+		}
+	}
+}
 
+func sign(v int) int {
+	if v > 0 {
+		return 1
+	}
+	if v < 0 {
+		return -1
+	}
+	return 0
 }
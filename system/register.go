@@ -1,7 +1,11 @@
 package system
 
-import "github.com/Tomislaw/far-worlds/ecs"
+import (
+	"github.com/Tomislaw/far-worlds/ecs"
+)
 
+// RegisterSystems registers all systems and events sent by them
 func RegisterSystems(manager *ecs.Manager) {
+	RegisterEvents(manager)
 	manager.RegisterSystems(&MovementSystem{})
 }
@@ -1,14 +1,268 @@
 package world
 
-import "github.com/Tomislaw/far-worlds/world/tile"
+import (
+	"encoding/json"
+	"fmt"
 
-const chunkWidth = 64
-const chunkHeight = 8
+	"github.com/Tomislaw/far-worlds/world/coord"
+	"github.com/Tomislaw/far-worlds/world/tile"
+)
 
+// Default size of chunk used when world is created without configuration
+const DefaultChunkWidth = 64
+const DefaultChunkHeight = 8
+
+// Chunk is column of width x width x height tiles. Local positions passed to its methods
+// must be inside chunk, like ones returned by Dimensions.ToLocal, methods panic on positions
+// outside of it and methods returning error return it instead.
 type Chunk struct {
-	tiles [chunkWidth][chunkWidth][chunkHeight]uint8 `json:"id"`
+	position coord.ChunkPosition
+
+	width  int
+	height int
+	tiles  []uint8
+
+	// light level of each tile, valid only when lit is set
+	light []uint8
+	lit   bool
+
+	// metadata is sparse, only tiles with non default values are stored
+	metadata map[int]TileMetadata
+
+	// dirty is set when chunk was modified since it was last saved
+	dirty bool
+
+	onTileChanged func(ch *Chunk, p coord.LocalPosition, old uint8)
 }
 
-func (ch Chunk) GetTile(x uint8, y uint8, z uint8) tile.Tile {
-	return tile.Atlas.Tiles[ch.tiles[x][y][z]]
+// chunkData is persisted form of chunk
+type chunkData struct {
+	Width    int                  `json:"width"`
+	Height   int                  `json:"height"`
+	Tiles    []uint8              `json:"tiles"`
+	Light    []uint8              `json:"light,omitempty"`
+	Metadata map[int]TileMetadata `json:"metadata,omitempty"`
+}
+
+// NewChunk creates empty chunk with width x width x height tiles
+func NewChunk(position coord.ChunkPosition, width int, height int) *Chunk {
+	return &Chunk{
+		position: position,
+		width:    width,
+		height:   height,
+		tiles:    make([]uint8, width*width*height),
+		light:    make([]uint8, width*width*height),
+	}
+}
+
+// Contains returns true if local position is inside chunk
+func (ch *Chunk) Contains(p coord.LocalPosition) bool {
+	return p.X >= 0 && p.X < ch.width && p.Y >= 0 && p.Y < ch.width && p.Z >= 0 && p.Z < ch.height
+}
+
+// tileIndex returns index of tile in flat storage, position outside of chunk would alias
+// another tile, so it panics
+func (ch *Chunk) tileIndex(p coord.LocalPosition) int {
+	if !ch.Contains(p) {
+		panic(fmt.Sprintf("Local position %v is outside of chunk %v", p, ch.position))
+	}
+	return (p.X*ch.width+p.Y)*ch.height + p.Z
+}
+
+func (ch *Chunk) tilePosition(index int) coord.LocalPosition {
+	return coord.LocalPosition{
+		X: index / ch.height / ch.width,
+		Y: index / ch.height % ch.width,
+		Z: index % ch.height,
+	}
+}
+
+// Position returns position of chunk on map
+func (ch *Chunk) Position() coord.ChunkPosition {
+	return ch.position
+}
+
+// Width returns count of tiles along x and y axis
+func (ch *Chunk) Width() int {
+	return ch.width
+}
+
+// Height returns count of z levels
+func (ch *Chunk) Height() int {
+	return ch.height
+}
+
+// GetTileID returns id of tile in atlas
+func (ch *Chunk) GetTileID(p coord.LocalPosition) uint8 {
+	return ch.tiles[ch.tileIndex(p)]
+}
+
+func (ch *Chunk) GetTile(p coord.LocalPosition) tile.Tile {
+	return tile.Atlas.Tiles[ch.tiles[ch.tileIndex(p)]]
+}
+
+// SetTile changes tile and clears its metadata, it panics if tile isn't declared in atlas
+func (ch *Chunk) SetTile(p coord.LocalPosition, id uint8) {
+	if !tile.Atlas.Contains(id) {
+		panic(fmt.Sprintf("Unknown tile %v", id))
+	}
+	index := ch.tileIndex(p)
+	old := ch.tiles[index]
+	ch.tiles[index] = id
+	delete(ch.metadata, index)
+	ch.emitTileChanged(p, old)
+}
+
+// SetTileWithMetadata changes tile and replaces its metadata, tile change is reported once.
+// Nothing is changed if metadata isn't valid for new tile.
+func (ch *Chunk) SetTileWithMetadata(p coord.LocalPosition, id uint8, metadata TileMetadata) error {
+	if !ch.Contains(p) {
+		return fmt.Errorf("local position %v is outside of chunk %v", p, ch.position)
+	}
+	if !tile.Atlas.Contains(id) {
+		return fmt.Errorf("unknown tile %v", id)
+	}
+	values, err := metadata.normalize(tile.Atlas.Tiles[id])
+	if err != nil {
+		return err
+	}
+	index := ch.tileIndex(p)
+	old := ch.tiles[index]
+	ch.tiles[index] = id
+	delete(ch.metadata, index)
+	if len(values) > 0 {
+		if ch.metadata == nil {
+			ch.metadata = make(map[int]TileMetadata)
+		}
+		ch.metadata[index] = values
+	}
+	ch.emitTileChanged(p, old)
+	return nil
+}
+
+// GetLight returns light level of tile
+func (ch *Chunk) GetLight(p coord.LocalPosition) uint8 {
+	return ch.light[ch.tileIndex(p)]
+}
+
+// setLight changes light level, light is derived from tiles so it doesn't mark chunk as modified
+func (ch *Chunk) setLight(p coord.LocalPosition, level uint8) {
+	ch.light[ch.tileIndex(p)] = level
+}
+
+// GetMetadata returns value of tile metadata field, or its default value if it was not set.
+// Returns nil if tile has no such field.
+func (ch *Chunk) GetMetadata(p coord.LocalPosition, name string) interface{} {
+	if v, ok := ch.metadata[ch.tileIndex(p)][name]; ok {
+		return v
+	}
+	field, ok := ch.GetTile(p).Field(name)
+	if !ok {
+		return nil
+	}
+	return field.DefaultValue()
+}
+
+// GetTileMetadata returns copy of all metadata values stored for tile
+func (ch *Chunk) GetTileMetadata(p coord.LocalPosition) TileMetadata {
+	return ch.metadata[ch.tileIndex(p)].copy()
+}
+
+// SetMetadata sets value of metadata field declared by tile, value equal to field default
+// removes stored value
+func (ch *Chunk) SetMetadata(p coord.LocalPosition, name string, value interface{}) error {
+	if !ch.Contains(p) {
+		return fmt.Errorf("local position %v is outside of chunk %v", p, ch.position)
+	}
+	values, err := TileMetadata{name: value}.normalize(ch.GetTile(p))
+	if err != nil {
+		return err
+	}
+
+	index := ch.tileIndex(p)
+	md, ok := ch.metadata[index]
+	if v, set := values[name]; !set {
+		// default value isn't stored
+		delete(md, name)
+		if ok && len(md) == 0 {
+			delete(ch.metadata, index)
+		}
+	} else {
+		if ch.metadata == nil {
+			ch.metadata = make(map[int]TileMetadata)
+		}
+		if !ok {
+			md = make(TileMetadata)
+			ch.metadata[index] = md
+		}
+		md[name] = v
+	}
+
+	ch.emitTileChanged(p, ch.tiles[index])
+	return nil
+}
+
+func (ch *Chunk) emitTileChanged(p coord.LocalPosition, old uint8) {
+	ch.dirty = true
+	if ch.onTileChanged != nil {
+		ch.onTileChanged(ch, p, old)
+	}
+}
+
+// MarshalJSON saves tiles together with their metadata
+func (ch *Chunk) MarshalJSON() ([]byte, error) {
+	return json.Marshal(chunkData{
+		Width:    ch.width,
+		Height:   ch.height,
+		Tiles:    ch.tiles,
+		Light:    ch.light,
+		Metadata: ch.metadata,
+	})
+}
+
+// UnmarshalJSON loads tiles and validates their ids and metadata against tile atlas.
+// If chunk was created with NewChunk, its size must match saved size.
+func (ch *Chunk) UnmarshalJSON(b []byte) error {
+	var data chunkData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+
+	if ch.tiles != nil && (data.Width != ch.width || data.Height != ch.height) {
+		return fmt.Errorf("chunk size %vx%v doesn't match saved size %vx%v",
+			ch.width, ch.height, data.Width, data.Height)
+	}
+	if len(data.Tiles) != data.Width*data.Width*data.Height {
+		return fmt.Errorf("invalid count of tiles in chunk: %v", len(data.Tiles))
+	}
+	for index, id := range data.Tiles {
+		if !tile.Atlas.Contains(id) {
+			return fmt.Errorf("unknown tile %v at index %v", id, index)
+		}
+	}
+	ch.width = data.Width
+	ch.height = data.Height
+	ch.tiles = data.Tiles
+
+	// chunks saved without light are lit again when loaded
+	ch.lit = len(data.Light) == len(data.Tiles)
+	ch.light = data.Light
+	if !ch.lit {
+		ch.light = make([]uint8, len(ch.tiles))
+	}
+
+	ch.metadata = make(map[int]TileMetadata, len(data.Metadata))
+	for index, md := range data.Metadata {
+		if index < 0 || index >= len(ch.tiles) {
+			return fmt.Errorf("metadata of tile %v is outside of chunk", index)
+		}
+		values, err := md.normalize(ch.GetTile(ch.tilePosition(index)))
+		if err != nil {
+			return err
+		}
+		if len(values) > 0 {
+			ch.metadata[index] = values
+		}
+	}
+	return nil
 }
@@ -0,0 +1,68 @@
+package world
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+	"github.com/Tomislaw/far-worlds/world/tile"
+)
+
+func TestChunkRejectsPositionsOutside(t *testing.T) {
+	ch := NewChunk(coord.ChunkPosition{}, 4, 2)
+	outside := coord.LocalPosition{X: 0, Y: 4, Z: 0}
+	if ch.Contains(outside) {
+		t.Fatalf("expected %v to be outside of chunk", outside)
+	}
+	if err := ch.SetMetadata(outside, LiquidLevelField, 1); err == nil {
+		t.Fatal("expected metadata outside of chunk to be rejected")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected tile outside of chunk to panic instead of aliasing another tile")
+		}
+		if ch.GetTileID(coord.LocalPosition{X: 1, Y: 0, Z: 0}) != 0 {
+			t.Fatal("expected tile inside chunk to be unchanged")
+		}
+	}()
+	ch.SetTile(outside, 1)
+}
+
+func TestDefaultMetadataIsNotStored(t *testing.T) {
+	ch := NewChunk(coord.ChunkPosition{}, 4, 2)
+	p := coord.LocalPosition{X: 1, Y: 1, Z: 0}
+	ch.SetTile(p, 6)
+
+	if err := ch.SetMetadata(p, LiquidLevelField, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.SetMetadata(p, LiquidLevelField, 8); err != nil {
+		t.Fatal(err)
+	}
+	if len(ch.metadata) != 0 {
+		t.Fatalf("expected default level to remove stored metadata, got %v", ch.metadata)
+	}
+	if level := ch.GetMetadata(p, LiquidLevelField); level != 8 {
+		t.Fatalf("expected default level 8, got %v", level)
+	}
+}
+
+func TestUnknownTilesRejected(t *testing.T) {
+	w := NewWorld(Config{})
+	m, _ := w.CreateMap(1, 2, 2)
+	p := coord.Position{X: 1, Y: 1, Z: 1}
+	unknown := uint8(len(tile.Atlas.Tiles))
+	if err := m.SetTile(p, unknown); err == nil {
+		t.Fatal("expected unknown tile to be rejected")
+	}
+	if err := m.SetTileWithMetadata(p, unknown, nil); err == nil {
+		t.Fatal("expected unknown tile with metadata to be rejected")
+	}
+
+	// base64 of tiles 255, 0, 0, 0
+	corrupt := `{"width":2,"height":1,"tiles":"/wAAAA=="}`
+	if err := json.Unmarshal([]byte(corrupt), &Chunk{}); err == nil {
+		t.Fatal("expected chunk with unknown tile to be rejected")
+	}
+}
@@ -0,0 +1,254 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+// Default time after which chunk without interest is unloaded
+const DefaultChunkIdleTimeout = 5 * time.Minute
+
+// unloadCheckInterval limits how often idle chunks are searched for
+const unloadCheckInterval = time.Second
+
+// GlobalChunksManager owns chunks of map, loading and unloading them when needed
+type GlobalChunksManager interface {
+	// GetChunk returns chunk, loading it if necessary. Returns nil if chunk is outside of map.
+	GetChunk(c coord.ChunkPosition) *Chunk
+	// PeekChunk returns chunk only if it's already loaded, reading chunk keeps it loaded
+	// like GetChunk does
+	PeekChunk(c coord.ChunkPosition) *Chunk
+	// SetInterest pins chunks in radius around center until interest is removed
+	SetInterest(id uint64, center coord.ChunkPosition, radius int)
+	// RemoveInterest unpins chunks pinned by interest
+	RemoveInterest(id uint64)
+	// Update unloads chunks which are idle longer than timeout
+	Update(now time.Time)
+	// SaveAll saves all modified chunks
+	SaveAll() error
+	// Metrics returns counters of loaded and unloaded chunks
+	Metrics() ChunkMetrics
+}
+
+// ChunkMetrics contains counters of chunk manager
+type ChunkMetrics struct {
+	Resident   uint64 `json:"resident"`
+	Loaded     uint64 `json:"loaded"`
+	Generated  uint64 `json:"generated"`
+	Unloaded   uint64 `json:"unloaded"`
+	Saved      uint64 `json:"saved"`
+	LoadErrors uint64 `json:"loadErrors"`
+	SaveErrors uint64 `json:"saveErrors"`
+}
+
+type interest struct {
+	center coord.ChunkPosition
+	radius int
+}
+
+type residentChunk struct {
+	chunk      *Chunk
+	lastAccess time.Time
+}
+
+// ChunkManager loads chunks from storage or generator on demand and unloads them
+// when there is no interest in them. Chunks are only accessed from map goroutine,
+// metrics can be read from any goroutine.
+type ChunkManager struct {
+	dimensions    coord.Dimensions
+	prefix        string
+	storage       Storage
+	generator     ChunkGenerator
+	idleTimeout   time.Duration
+	onTileChanged func(ch *Chunk, p coord.LocalPosition, old uint8)
+	onChunkLoaded func(ch *Chunk)
+
+	chunks    map[coord.ChunkPosition]*residentChunk
+	interests map[uint64]interest
+	lastCheck time.Time
+
+	metrics ChunkMetrics
+}
+
+// NewChunkManager creates chunk manager. Chunks are saved in storage under prefix, if
+// storage is nil modified chunks are kept in memory forever. Generator may be nil.
+func NewChunkManager(dimensions coord.Dimensions, prefix string, storage Storage, generator ChunkGenerator, idleTimeout time.Duration) *ChunkManager {
+	if idleTimeout == 0 {
+		idleTimeout = DefaultChunkIdleTimeout
+	}
+	return &ChunkManager{
+		dimensions:  dimensions,
+		prefix:      prefix,
+		storage:     storage,
+		generator:   generator,
+		idleTimeout: idleTimeout,
+		chunks:      make(map[coord.ChunkPosition]*residentChunk),
+		interests:   make(map[uint64]interest),
+	}
+}
+
+func (cm *ChunkManager) key(c coord.ChunkPosition) string {
+	return fmt.Sprintf("%v/chunks/%v_%v.json", cm.prefix, c.X, c.Y)
+}
+
+func (cm *ChunkManager) GetChunk(c coord.ChunkPosition) *Chunk {
+	if !cm.dimensions.ContainsChunk(c) {
+		return nil
+	}
+	rc, ok := cm.chunks[c]
+	if !ok {
+		rc = &residentChunk{chunk: cm.load(c)}
+		cm.chunks[c] = rc
+		atomic.AddUint64(&cm.metrics.Resident, 1)
+		if cm.onChunkLoaded != nil {
+			cm.onChunkLoaded(rc.chunk)
+		}
+	}
+	rc.lastAccess = time.Now()
+	return rc.chunk
+}
+
+func (cm *ChunkManager) PeekChunk(c coord.ChunkPosition) *Chunk {
+	if rc, ok := cm.chunks[c]; ok {
+		// chunks read by pathfinding or placement of entities without loaders stay loaded
+		rc.lastAccess = time.Now()
+		return rc.chunk
+	}
+	return nil
+}
+
+// IsLoaded returns true if chunk is resident in memory
+func (cm *ChunkManager) IsLoaded(c coord.ChunkPosition) bool {
+	_, ok := cm.chunks[c]
+	return ok
+}
+
+// LoadedChunks returns all resident chunks
+func (cm *ChunkManager) LoadedChunks() []*Chunk {
+	result := make([]*Chunk, 0, len(cm.chunks))
+	for _, rc := range cm.chunks {
+		result = append(result, rc.chunk)
+	}
+	return result
+}
+
+func (cm *ChunkManager) load(c coord.ChunkPosition) *Chunk {
+	ch := NewChunk(c, cm.dimensions.ChunkWidth, cm.dimensions.ChunkHeight)
+	ch.onTileChanged = cm.onTileChanged
+
+	if cm.storage != nil {
+		data, err := cm.storage.Load(cm.key(c))
+		if err == nil {
+			err = json.Unmarshal(data, ch)
+		}
+		if err == nil {
+			atomic.AddUint64(&cm.metrics.Loaded, 1)
+			return ch
+		}
+		if err != ErrNotFound {
+			// chunk is regenerated, but never overwrites broken save until modified
+			fmt.Printf("Failed to load chunk %v: %v\n", c, err.Error())
+			atomic.AddUint64(&cm.metrics.LoadErrors, 1)
+			ch = NewChunk(c, cm.dimensions.ChunkWidth, cm.dimensions.ChunkHeight)
+			ch.onTileChanged = cm.onTileChanged
+		}
+	}
+
+	if cm.generator != nil {
+		cm.generator.Generate(ch)
+	}
+	atomic.AddUint64(&cm.metrics.Generated, 1)
+	return ch
+}
+
+func (cm *ChunkManager) save(ch *Chunk) error {
+	if cm.storage == nil || !ch.dirty {
+		return nil
+	}
+	data, err := json.Marshal(ch)
+	if err == nil {
+		err = cm.storage.Save(cm.key(ch.position), data)
+	}
+	if err != nil {
+		atomic.AddUint64(&cm.metrics.SaveErrors, 1)
+		return fmt.Errorf("failed to save chunk %v: %v", ch.position, err)
+	}
+	ch.dirty = false
+	atomic.AddUint64(&cm.metrics.Saved, 1)
+	return nil
+}
+
+func (cm *ChunkManager) SetInterest(id uint64, center coord.ChunkPosition, radius int) {
+	cm.interests[id] = interest{center: center, radius: radius}
+	for x := center.X - radius; x <= center.X+radius; x++ {
+		for y := center.Y - radius; y <= center.Y+radius; y++ {
+			cm.GetChunk(coord.ChunkPosition{X: x, Y: y})
+		}
+	}
+}
+
+func (cm *ChunkManager) RemoveInterest(id uint64) {
+	delete(cm.interests, id)
+}
+
+// IsPinned returns true if chunk is in radius of any interest
+func (cm *ChunkManager) IsPinned(c coord.ChunkPosition) bool {
+	for _, i := range cm.interests {
+		if i.center.Chebyshev(c) <= i.radius {
+			return true
+		}
+	}
+	return false
+}
+
+func (cm *ChunkManager) Update(now time.Time) {
+	if now.Sub(cm.lastCheck) < unloadCheckInterval {
+		return
+	}
+	cm.lastCheck = now
+
+	for c, rc := range cm.chunks {
+		if now.Sub(rc.lastAccess) < cm.idleTimeout || cm.IsPinned(c) {
+			continue
+		}
+		if rc.chunk.dirty && cm.storage == nil {
+			continue
+		}
+		if err := cm.save(rc.chunk); err != nil {
+			fmt.Println(err.Error())
+			continue
+		}
+		delete(cm.chunks, c)
+		atomic.AddUint64(&cm.metrics.Resident, ^uint64(0))
+		atomic.AddUint64(&cm.metrics.Unloaded, 1)
+	}
+}
+
+func (cm *ChunkManager) SaveAll() error {
+	var failed []string
+	for _, rc := range cm.chunks {
+		if err := cm.save(rc.chunk); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%v chunks not saved: %v", len(failed), failed)
+	}
+	return nil
+}
+
+func (cm *ChunkManager) Metrics() ChunkMetrics {
+	return ChunkMetrics{
+		Resident:   atomic.LoadUint64(&cm.metrics.Resident),
+		Loaded:     atomic.LoadUint64(&cm.metrics.Loaded),
+		Generated:  atomic.LoadUint64(&cm.metrics.Generated),
+		Unloaded:   atomic.LoadUint64(&cm.metrics.Unloaded),
+		Saved:      atomic.LoadUint64(&cm.metrics.Saved),
+		LoadErrors: atomic.LoadUint64(&cm.metrics.LoadErrors),
+		SaveErrors: atomic.LoadUint64(&cm.metrics.SaveErrors),
+	}
+}
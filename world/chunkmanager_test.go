@@ -0,0 +1,101 @@
+package world
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+func newTestChunkManager(storage Storage) *ChunkManager {
+	dimensions := coord.Dimensions{ChunkWidth: 4, ChunkHeight: 2, MapWidth: 4, MapHeight: 4}
+	return NewChunkManager(dimensions, "test", storage, nil, time.Minute)
+}
+
+func TestIdleChunksUnload(t *testing.T) {
+	cm := newTestChunkManager(nil)
+	idle, pinned := coord.ChunkPosition{X: 0, Y: 0}, coord.ChunkPosition{X: 3, Y: 3}
+	cm.GetChunk(idle)
+	cm.SetInterest(1, pinned, 0)
+	now := time.Now()
+
+	cm.Update(now.Add(30 * time.Second))
+	if !cm.IsLoaded(idle) {
+		t.Fatal("expected chunk to stay loaded before timeout")
+	}
+	cm.Update(now.Add(2 * time.Minute))
+	if cm.IsLoaded(idle) || !cm.IsLoaded(pinned) {
+		t.Fatal("expected idle chunk to be unloaded and pinned chunk to stay")
+	}
+	if metrics := cm.Metrics(); metrics.Resident != 1 || metrics.Unloaded != 1 || metrics.Generated != 2 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+
+	cm.RemoveInterest(1)
+	cm.Update(now.Add(4 * time.Minute))
+	if cm.IsLoaded(pinned) {
+		t.Fatal("expected chunk to be unloaded when interest is removed")
+	}
+}
+
+func TestPeekKeepsChunkLoaded(t *testing.T) {
+	cm := newTestChunkManager(nil)
+	c := coord.ChunkPosition{X: 1, Y: 1}
+	cm.GetChunk(c)
+	cm.chunks[c].lastAccess = time.Now().Add(-time.Hour)
+
+	cm.PeekChunk(c)
+	cm.Update(time.Now().Add(30 * time.Second))
+	if !cm.IsLoaded(c) {
+		t.Fatal("expected read chunk to stay loaded")
+	}
+}
+
+func TestUpdateChecksIdleChunksPeriodically(t *testing.T) {
+	cm := newTestChunkManager(nil)
+	c := coord.ChunkPosition{X: 1, Y: 1}
+	now := time.Now()
+	cm.Update(now)
+	cm.GetChunk(c)
+	cm.chunks[c].lastAccess = now.Add(-time.Hour)
+
+	cm.Update(now.Add(unloadCheckInterval / 2))
+	if !cm.IsLoaded(c) {
+		t.Fatal("expected idle chunks not to be checked before interval passes")
+	}
+	cm.Update(now.Add(unloadCheckInterval))
+	if cm.IsLoaded(c) {
+		t.Fatal("expected idle chunk to be unloaded")
+	}
+}
+
+func TestModifiedChunksSavedOnUnload(t *testing.T) {
+	storage := NewFileStorage(t.TempDir())
+	cm := newTestChunkManager(storage)
+	c := coord.ChunkPosition{X: 2, Y: 1}
+	p := coord.LocalPosition{X: 1, Y: 2, Z: 1}
+	cm.GetChunk(c).SetTile(p, 1)
+
+	cm.Update(time.Now().Add(2 * time.Minute))
+	if cm.IsLoaded(c) {
+		t.Fatal("expected modified chunk to be saved and unloaded")
+	}
+	if metrics := cm.Metrics(); metrics.Saved != 1 || metrics.Unloaded != 1 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+
+	if id := cm.GetChunk(c).GetTileID(p); id != 1 {
+		t.Fatalf("expected saved tile to be loaded, got %v", id)
+	}
+	if metrics := cm.Metrics(); metrics.Loaded != 1 {
+		t.Fatalf("expected chunk to be loaded from storage, got %+v", metrics)
+	}
+
+	// without storage modified chunks are never dropped
+	memory := newTestChunkManager(nil)
+	memory.GetChunk(c).SetTile(p, 1)
+	memory.Update(time.Now().Add(2 * time.Minute))
+	if !memory.IsLoaded(c) {
+		t.Fatal("expected modified chunk without storage to stay loaded")
+	}
+}
@@ -0,0 +1,267 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTimeScale makes in-game day last 20 real minutes
+const DefaultTimeScale = 72
+
+// DefaultDaysPerSeason is length of season in in-game days
+const DefaultDaysPerSeason = 30
+
+// secondsPerDay is length of in-game day in in-game seconds
+const secondsPerDay = 24 * 60 * 60
+
+// clockTickInterval is real time between checks for clock events
+const clockTickInterval = 100 * time.Millisecond
+
+// clockKey is storage key of saved clock
+const clockKey = "clock.json"
+
+// ClockConfig contains settings of world clock
+type ClockConfig struct {
+	// Scale is count of in-game seconds passing during one real second, defaults to DefaultTimeScale
+	Scale float64
+	// DaysPerSeason defaults to DefaultDaysPerSeason
+	DaysPerSeason int
+	// DawnHour is hour of day when sun rises, defaults to 6
+	DawnHour float64
+	// DuskHour is hour of day when sun sets, defaults to 20
+	DuskHour float64
+}
+
+// Season of year, each year has four seasons
+type Season int
+
+const (
+	Spring Season = iota
+	Summer
+	Autumn
+	Winter
+)
+
+func (s Season) String() string {
+	switch s {
+	case Spring:
+		return "spring"
+	case Summer:
+		return "summer"
+	case Autumn:
+		return "autumn"
+	case Winter:
+		return "winter"
+	}
+	return fmt.Sprintf("season-%d", int(s))
+}
+
+// ClockTime is in-game time of day and date
+type ClockTime struct {
+	// Elapsed is count of in-game seconds since world was created
+	Elapsed float64
+	// Day is count of days since world was created
+	Day         int
+	Hour        int
+	Minute      int
+	Second      int
+	Season      Season
+	DayOfSeason int
+	Year        int
+	// Daylight is true between dawn and dusk
+	Daylight bool
+}
+
+// ClockEventKind is type of clock event
+type ClockEventKind int
+
+const (
+	// NewDay is emitted at midnight
+	NewDay ClockEventKind = iota
+	// NewSeason is emitted at midnight of first day of season, after NewDay
+	NewSeason
+	Dawn
+	Dusk
+)
+
+func (k ClockEventKind) String() string {
+	switch k {
+	case NewDay:
+		return "new day"
+	case NewSeason:
+		return "new season"
+	case Dawn:
+		return "dawn"
+	case Dusk:
+		return "dusk"
+	}
+	return fmt.Sprintf("clock-event-%d", int(k))
+}
+
+// ClockEvent is broadcasted to all maps of running world when clock reaches dawn, dusk or
+// midnight. Maps emit it as ecs event readable by systems, it can be also handled with
+// Map.OnMessage.
+type ClockEvent struct {
+	Kind ClockEventKind
+	Time ClockTime
+}
+
+// Clock measures in-game time of world. Time passes only while world is running.
+// Safe for concurrent use.
+type Clock struct {
+	config ClockConfig
+
+	lock    sync.RWMutex
+	base    float64
+	started time.Time
+	running bool
+}
+
+// NewClock creates stopped clock with given in-game seconds elapsed
+func NewClock(config ClockConfig, elapsed float64) *Clock {
+	if config.Scale == 0 {
+		config.Scale = DefaultTimeScale
+	}
+	if config.DaysPerSeason == 0 {
+		config.DaysPerSeason = DefaultDaysPerSeason
+	}
+	if config.DawnHour == 0 && config.DuskHour == 0 {
+		config.DawnHour = 6
+		config.DuskHour = 20
+	}
+	return &Clock{config: config, base: elapsed}
+}
+
+// Config returns settings of clock
+func (c *Clock) Config() ClockConfig {
+	return c.config
+}
+
+// Elapsed returns count of in-game seconds since world was created
+func (c *Clock) Elapsed() float64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.running {
+		return c.base
+	}
+	return c.base + time.Since(c.started).Seconds()*c.config.Scale
+}
+
+// Now returns current in-game time
+func (c *Clock) Now() ClockTime {
+	return c.At(c.Elapsed())
+}
+
+// At returns in-game time after given count of in-game seconds
+func (c *Clock) At(elapsed float64) ClockTime {
+	day := int(math.Floor(elapsed / secondsPerDay))
+	seconds := int(elapsed - float64(day)*secondsPerDay)
+	seasons := day / c.config.DaysPerSeason
+	hour := float64(seconds) / 3600
+	return ClockTime{
+		Elapsed:     elapsed,
+		Day:         day,
+		Hour:        seconds / 3600,
+		Minute:      seconds / 60 % 60,
+		Second:      seconds % 60,
+		Season:      Season(seasons % 4),
+		DayOfSeason: day % c.config.DaysPerSeason,
+		Year:        seasons / 4,
+		Daylight:    hour >= c.config.DawnHour && hour < c.config.DuskHour,
+	}
+}
+
+// Set changes current in-game time, events between old and new time aren't emitted.
+// Returns error if elapsed is negative.
+func (c *Clock) Set(elapsed float64) error {
+	if elapsed < 0 || math.IsNaN(elapsed) {
+		return fmt.Errorf("invalid clock time %v", elapsed)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.base = elapsed
+	c.started = time.Now()
+	return nil
+}
+
+func (c *Clock) start() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.running {
+		c.running = true
+		c.started = time.Now()
+	}
+}
+
+func (c *Clock) stop() {
+	elapsed := c.Elapsed()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.base = elapsed
+	c.running = false
+}
+
+// events returns events which happened after from and up to to, in order they happened
+func (c *Clock) events(from float64, to float64) []ClockEvent {
+	var result []ClockEvent
+	add := func(kind ClockEventKind, at float64) {
+		if at > from && at <= to {
+			result = append(result, ClockEvent{Kind: kind, Time: c.At(at)})
+		}
+	}
+	for day := math.Floor(from / secondsPerDay); day*secondsPerDay <= to; day++ {
+		midnight := day * secondsPerDay
+		add(NewDay, midnight)
+		if int(day)%c.config.DaysPerSeason == 0 {
+			add(NewSeason, midnight)
+		}
+		add(Dawn, midnight+c.config.DawnHour*3600)
+		add(Dusk, midnight+c.config.DuskHour*3600)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Time.Elapsed < result[j].Time.Elapsed })
+	return result
+}
+
+type clockData struct {
+	Elapsed float64 `json:"elapsed"`
+}
+
+// loadClock creates clock with time saved in storage
+func loadClock(config ClockConfig, storage Storage) (*Clock, error) {
+	if storage == nil {
+		return NewClock(config, 0), nil
+	}
+	data, err := storage.Load(clockKey)
+	if err == ErrNotFound {
+		return NewClock(config, 0), nil
+	}
+	var saved clockData
+	if err == nil {
+		err = json.Unmarshal(data, &saved)
+	}
+	if err == nil && saved.Elapsed < 0 {
+		err = fmt.Errorf("invalid clock time %v", saved.Elapsed)
+	}
+	if err != nil {
+		return NewClock(config, 0), fmt.Errorf("failed to load clock: %v", err)
+	}
+	return NewClock(config, saved.Elapsed), nil
+}
+
+func (c *Clock) save(storage Storage) error {
+	if storage == nil {
+		return nil
+	}
+	data, err := json.Marshal(clockData{Elapsed: c.Elapsed()})
+	if err == nil {
+		err = storage.Save(clockKey, data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save clock: %v", err)
+	}
+	return nil
+}
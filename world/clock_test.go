@@ -0,0 +1,69 @@
+package world
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClockEventOrder(t *testing.T) {
+	// dawn at midnight happens together with new day and new season
+	c := NewClock(ClockConfig{DaysPerSeason: 2, DawnHour: 0, DuskHour: 12}, 0)
+	events := c.events(0, 2*secondsPerDay)
+	expected := []ClockEventKind{Dusk, NewDay, Dawn, Dusk, NewDay, NewSeason, Dawn}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %v events, got %+v", len(expected), events)
+	}
+	for i, event := range events {
+		if event.Kind != expected[i] {
+			t.Errorf("expected event %v to be %v, got %v", i, expected[i], event.Kind)
+		}
+	}
+	if last := events[len(events)-1].Time; last.Day != 2 || last.Season != Summer || last.DayOfSeason != 0 {
+		t.Fatalf("unexpected time of last event %+v", last)
+	}
+	if events := c.events(2*secondsPerDay, 2*secondsPerDay); len(events) != 0 {
+		t.Fatalf("expected no events in empty range, got %+v", events)
+	}
+}
+
+func TestClockSetRejectsNegativeTime(t *testing.T) {
+	c := NewClock(ClockConfig{}, 100)
+	for _, elapsed := range []float64{-1, math.NaN()} {
+		if err := c.Set(elapsed); err == nil {
+			t.Errorf("expected time %v to be rejected", elapsed)
+		}
+	}
+	if c.Elapsed() != 100 {
+		t.Fatalf("expected rejected time not to change clock, got %v", c.Elapsed())
+	}
+	if err := c.Set(0); err != nil || c.Elapsed() != 0 {
+		t.Fatalf("expected clock to be set to 0, got %v and %v", c.Elapsed(), err)
+	}
+}
+
+func TestClockPersistence(t *testing.T) {
+	storage := NewFileStorage(t.TempDir())
+	if c, err := loadClock(ClockConfig{}, storage); err != nil || c.Elapsed() != 0 {
+		t.Fatalf("expected new clock when nothing is saved, got %v and %v", c.Elapsed(), err)
+	}
+
+	c := NewClock(ClockConfig{}, 0)
+	c.Set(1.5 * secondsPerDay)
+	if err := c.save(storage); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadClock(ClockConfig{}, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Elapsed() != 1.5*secondsPerDay || loaded.Now().Hour != 12 {
+		t.Fatalf("expected saved time to be loaded, got %+v", loaded.Now())
+	}
+
+	for _, data := range []string{"{", `{"elapsed":-1}`} {
+		storage.Save(clockKey, []byte(data))
+		if _, err := loadClock(ClockConfig{}, storage); err == nil {
+			t.Fatalf("expected invalid clock %v to be reported", data)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package coord
+
+import "fmt"
+
+// Position is global position of tile on map
+type Position struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// ChunkPosition is position of chunk on map, in chunks
+type ChunkPosition struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// LocalPosition is position of tile inside its chunk
+type LocalPosition struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// Offsets of neighboring tiles
+var (
+	// Neighbors4 are tiles sharing edge on the same z level
+	Neighbors4 = []Position{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}}
+	// Neighbors8 are tiles sharing edge or corner on the same z level
+	Neighbors8 = []Position{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0},
+		{1, 1, 0}, {1, -1, 0}, {-1, 1, 0}, {-1, -1, 0}}
+	// Neighbors6 are tiles sharing face in 3d
+	Neighbors6 = []Position{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+)
+
+func (p Position) String() string {
+	return fmt.Sprintf("(%v,%v,%v)", p.X, p.Y, p.Z)
+}
+
+// Add returns sum of both positions
+func (p Position) Add(o Position) Position {
+	return Position{p.X + o.X, p.Y + o.Y, p.Z + o.Z}
+}
+
+// Sub returns difference of both positions
+func (p Position) Sub(o Position) Position {
+	return Position{p.X - o.X, p.Y - o.Y, p.Z - o.Z}
+}
+
+// Neighbors returns positions of neighbors using given offsets, e.g. Neighbors4
+func (p Position) Neighbors(offsets []Position) []Position {
+	result := make([]Position, len(offsets))
+	for i, offset := range offsets {
+		result[i] = p.Add(offset)
+	}
+	return result
+}
+
+// Manhattan returns manhattan distance between positions
+func (p Position) Manhattan(o Position) int {
+	return abs(p.X-o.X) + abs(p.Y-o.Y) + abs(p.Z-o.Z)
+}
+
+// Chebyshev returns chebyshev distance between positions, diagonal step counts as 1
+func (p Position) Chebyshev(o Position) int {
+	return max(abs(p.X-o.X), max(abs(p.Y-o.Y), abs(p.Z-o.Z)))
+}
+
+func (c ChunkPosition) String() string {
+	return fmt.Sprintf("(%v,%v)", c.X, c.Y)
+}
+
+// Chebyshev returns chebyshev distance between chunks
+func (c ChunkPosition) Chebyshev(o ChunkPosition) int {
+	return max(abs(c.X-o.X), abs(c.Y-o.Y))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// floorDiv divides rounding towards negative infinity, so tiles with negative
+// coordinates land in negative chunks
+func floorDiv(a int, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// Size is extent of box in tiles
+type Size struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Z int `json:"z"`
+}
+
+// Volume returns count of tiles inside box of this size
+func (s Size) Volume() int {
+	return s.X * s.Y * s.Z
+}
+
+// Box returns positions of all tiles inside box starting at origin
+func (s Size) Box(origin Position) []Position {
+	result := make([]Position, 0, s.Volume())
+	for x := 0; x < s.X; x++ {
+		for y := 0; y < s.Y; y++ {
+			for z := 0; z < s.Z; z++ {
+				result = append(result, origin.Add(Position{x, y, z}))
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package coord
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFloorDiv(t *testing.T) {
+	tests := []struct {
+		a, b, want int
+	}{
+		{0, 16, 0},
+		{15, 16, 0},
+		{16, 16, 1},
+		{-1, 16, -1},
+		{-16, 16, -1},
+		{-17, 16, -2},
+		{-32, 16, -2},
+		{7, -2, -4},
+		{-7, -2, 3},
+	}
+	for _, test := range tests {
+		if got := floorDiv(test.a, test.b); got != test.want {
+			t.Errorf("floorDiv(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestDistances(t *testing.T) {
+	tests := []struct {
+		a, b                 Position
+		manhattan, chebyshev int
+	}{
+		{Position{0, 0, 0}, Position{0, 0, 0}, 0, 0},
+		{Position{0, 0, 0}, Position{3, -4, 1}, 8, 4},
+		{Position{-2, -2, 0}, Position{2, 1, 0}, 7, 4},
+		{Position{5, 0, -3}, Position{5, 0, 3}, 6, 6},
+	}
+	for _, test := range tests {
+		if got := test.a.Manhattan(test.b); got != test.manhattan {
+			t.Errorf("%v.Manhattan(%v) = %v, want %v", test.a, test.b, got, test.manhattan)
+		}
+		if got := test.a.Chebyshev(test.b); got != test.chebyshev {
+			t.Errorf("%v.Chebyshev(%v) = %v, want %v", test.a, test.b, got, test.chebyshev)
+		}
+		if got := test.b.Manhattan(test.a); got != test.manhattan {
+			t.Errorf("expected manhattan distance to be symmetric, got %v", got)
+		}
+	}
+
+	if got := (ChunkPosition{-1, 2}).Chebyshev(ChunkPosition{2, -1}); got != 3 {
+		t.Errorf("expected chebyshev distance of chunks 3, got %v", got)
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	p := Position{-1, 0, 2}
+	tests := []struct {
+		offsets []Position
+		want    []Position
+	}{
+		{Neighbors4, []Position{{0, 0, 2}, {-2, 0, 2}, {-1, 1, 2}, {-1, -1, 2}}},
+		{Neighbors6, []Position{{0, 0, 2}, {-2, 0, 2}, {-1, 1, 2}, {-1, -1, 2}, {-1, 0, 3}, {-1, 0, 1}}},
+	}
+	for _, test := range tests {
+		if got := p.Neighbors(test.offsets); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("expected neighbors %v, got %v", test.want, got)
+		}
+	}
+
+	seen := make(map[Position]bool)
+	for _, n := range p.Neighbors(Neighbors8) {
+		if d := n.Chebyshev(p); d != 1 || n.Z != p.Z {
+			t.Errorf("expected %v to be neighbor of %v on the same z level", n, p)
+		}
+		seen[n] = true
+	}
+	if len(seen) != 8 {
+		t.Errorf("expected 8 distinct neighbors, got %v", len(seen))
+	}
+}
@@ -0,0 +1,83 @@
+package coord
+
+import "fmt"
+
+// Dimensions describes size of chunks and map, used to convert between
+// global, chunk and local positions
+type Dimensions struct {
+	// ChunkWidth is count of tiles in chunk along x and y axis
+	ChunkWidth int
+	// ChunkHeight is count of z levels
+	ChunkHeight int
+	// MapWidth is count of chunks along x axis, 0 means map is unbounded along x
+	MapWidth int
+	// MapHeight is count of chunks along y axis, 0 means map is unbounded along y
+	MapHeight int
+}
+
+// Bounded returns true if map has limited size along both axis
+func (d Dimensions) Bounded() bool {
+	return d.MapWidth > 0 && d.MapHeight > 0
+}
+
+// ChunkVolume returns count of tiles in single chunk
+func (d Dimensions) ChunkVolume() int {
+	return d.ChunkWidth * d.ChunkWidth * d.ChunkHeight
+}
+
+// ToChunk returns position of chunk containing tile
+func (d Dimensions) ToChunk(p Position) ChunkPosition {
+	return ChunkPosition{floorDiv(p.X, d.ChunkWidth), floorDiv(p.Y, d.ChunkWidth)}
+}
+
+// ToLocal returns position of tile inside its chunk
+func (d Dimensions) ToLocal(p Position) LocalPosition {
+	c := d.ToChunk(p)
+	return LocalPosition{p.X - c.X*d.ChunkWidth, p.Y - c.Y*d.ChunkWidth, p.Z}
+}
+
+// ToGlobal returns global position of tile inside chunk
+func (d Dimensions) ToGlobal(c ChunkPosition, l LocalPosition) Position {
+	return Position{c.X*d.ChunkWidth + l.X, c.Y*d.ChunkWidth + l.Y, l.Z}
+}
+
+// ContainsChunk returns true if chunk is inside map
+func (d Dimensions) ContainsChunk(c ChunkPosition) bool {
+	if d.MapWidth > 0 && (c.X < 0 || c.X >= d.MapWidth) {
+		return false
+	}
+	if d.MapHeight > 0 && (c.Y < 0 || c.Y >= d.MapHeight) {
+		return false
+	}
+	return true
+}
+
+// ContainsLocal returns true if position is inside chunk
+func (d Dimensions) ContainsLocal(l LocalPosition) bool {
+	return l.X >= 0 && l.Y >= 0 && l.Z >= 0 &&
+		l.X < d.ChunkWidth && l.Y < d.ChunkWidth && l.Z < d.ChunkHeight
+}
+
+// Contains returns true if tile is inside map
+func (d Dimensions) Contains(p Position) bool {
+	return p.Z >= 0 && p.Z < d.ChunkHeight && d.ContainsChunk(d.ToChunk(p))
+}
+
+// ValidateSize returns error if dimensions can't be used to create map
+func (d Dimensions) ValidateSize() error {
+	if d.ChunkWidth <= 0 || d.ChunkHeight <= 0 {
+		return fmt.Errorf("invalid chunk size %vx%vx%v", d.ChunkWidth, d.ChunkWidth, d.ChunkHeight)
+	}
+	if d.MapWidth < 0 || d.MapHeight < 0 {
+		return fmt.Errorf("invalid map size %vx%v", d.MapWidth, d.MapHeight)
+	}
+	return nil
+}
+
+// Validate returns error if tile is outside of map
+func (d Dimensions) Validate(p Position) error {
+	if !d.Contains(p) {
+		return fmt.Errorf("position %v is outside of map", p)
+	}
+	return nil
+}
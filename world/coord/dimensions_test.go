@@ -0,0 +1,85 @@
+package coord
+
+import "testing"
+
+func TestConversions(t *testing.T) {
+	d := Dimensions{ChunkWidth: 16, ChunkHeight: 4}
+	tests := []struct {
+		p     Position
+		chunk ChunkPosition
+		local LocalPosition
+	}{
+		{Position{0, 0, 0}, ChunkPosition{0, 0}, LocalPosition{0, 0, 0}},
+		{Position{15, 15, 3}, ChunkPosition{0, 0}, LocalPosition{15, 15, 3}},
+		{Position{16, 0, 1}, ChunkPosition{1, 0}, LocalPosition{0, 0, 1}},
+		{Position{-1, 0, 0}, ChunkPosition{-1, 0}, LocalPosition{15, 0, 0}},
+		{Position{-16, -17, 2}, ChunkPosition{-1, -2}, LocalPosition{0, 15, 2}},
+		{Position{-17, 33, 0}, ChunkPosition{-2, 2}, LocalPosition{15, 1, 0}},
+	}
+	for _, test := range tests {
+		if got := d.ToChunk(test.p); got != test.chunk {
+			t.Errorf("ToChunk(%v) = %v, want %v", test.p, got, test.chunk)
+		}
+		local := d.ToLocal(test.p)
+		if local != test.local {
+			t.Errorf("ToLocal(%v) = %v, want %v", test.p, local, test.local)
+		}
+		if !d.ContainsLocal(local) {
+			t.Errorf("expected local position %v of %v to be inside chunk", local, test.p)
+		}
+		if got := d.ToGlobal(test.chunk, local); got != test.p {
+			t.Errorf("ToGlobal(%v, %v) = %v, want %v", test.chunk, local, got, test.p)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	bounded := Dimensions{ChunkWidth: 16, ChunkHeight: 4, MapWidth: 2, MapHeight: 3}
+	unbounded := Dimensions{ChunkWidth: 16, ChunkHeight: 4}
+	tests := []struct {
+		d    Dimensions
+		p    Position
+		want bool
+	}{
+		{bounded, Position{0, 0, 0}, true},
+		{bounded, Position{31, 47, 3}, true},
+		{bounded, Position{32, 0, 0}, false},
+		{bounded, Position{0, 48, 0}, false},
+		{bounded, Position{-1, 0, 0}, false},
+		{bounded, Position{0, 0, 4}, false},
+		{bounded, Position{0, 0, -1}, false},
+		{unbounded, Position{-1000, 1000, 0}, true},
+		{unbounded, Position{0, 0, 4}, false},
+		{Dimensions{ChunkWidth: 16, ChunkHeight: 4, MapWidth: 2}, Position{0, -100, 0}, true},
+		{Dimensions{ChunkWidth: 16, ChunkHeight: 4, MapWidth: 2}, Position{-1, 0, 0}, false},
+	}
+	for _, test := range tests {
+		if got := test.d.Contains(test.p); got != test.want {
+			t.Errorf("%+v Contains(%v) = %v, want %v", test.d, test.p, got, test.want)
+		}
+		if err := test.d.Validate(test.p); (err == nil) != test.want {
+			t.Errorf("%+v Validate(%v) = %v, want valid %v", test.d, test.p, err, test.want)
+		}
+	}
+}
+
+func TestValidateSize(t *testing.T) {
+	tests := []struct {
+		d     Dimensions
+		valid bool
+	}{
+		{Dimensions{ChunkWidth: 16, ChunkHeight: 4, MapWidth: 2, MapHeight: 2}, true},
+		{Dimensions{ChunkWidth: 16, ChunkHeight: 4}, true},
+		{Dimensions{ChunkWidth: 16, ChunkHeight: 4, MapWidth: 2}, true},
+		{Dimensions{ChunkWidth: 0, ChunkHeight: 4}, false},
+		{Dimensions{ChunkWidth: 16, ChunkHeight: 0}, false},
+		{Dimensions{ChunkWidth: -16, ChunkHeight: 4}, false},
+		{Dimensions{ChunkWidth: 16, ChunkHeight: 4, MapWidth: -1}, false},
+		{Dimensions{ChunkWidth: 16, ChunkHeight: 4, MapHeight: -1}, false},
+	}
+	for _, test := range tests {
+		if err := test.d.ValidateSize(); (err == nil) != test.valid {
+			t.Errorf("%+v ValidateSize() = %v, want valid %v", test.d, err, test.valid)
+		}
+	}
+}
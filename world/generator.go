@@ -0,0 +1,24 @@
+package world
+
+import "github.com/Tomislaw/far-worlds/world/coord"
+
+// ChunkGenerator fills newly created chunks which were never saved
+type ChunkGenerator interface {
+	Generate(ch *Chunk)
+}
+
+// FlatGenerator fills bottom z levels of every chunk with single tile
+type FlatGenerator struct {
+	Tile   uint8
+	Levels int
+}
+
+func (g FlatGenerator) Generate(ch *Chunk) {
+	for x := 0; x < ch.width; x++ {
+		for y := 0; y < ch.width; y++ {
+			for z := 0; z < g.Levels && z < ch.height; z++ {
+				ch.tiles[ch.tileIndex(coord.LocalPosition{X: x, Y: y, Z: z})] = g.Tile
+			}
+		}
+	}
+}
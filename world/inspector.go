@@ -0,0 +1,240 @@
+package world
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tomislaw/far-worlds/ecs"
+)
+
+// Inspector serves state of ecs managers of all maps as JSON, it's meant for debugging:
+//
+//	GET  /maps                            summaries of all maps
+//	GET  /maps/<id>                       summary of map
+//	GET  /maps/<id>/entities              ids of entities of map
+//	GET  /maps/<id>/entities/<entity>     components, tags, hierarchy and relations of entity
+//	POST /maps/<id>/systems/<name>?enabled=false
+//	                                      disables or enables system, e.g. *system.MovementSystem
+//
+// Requests are handled between ticks of running maps, so they don't race with systems.
+type Inspector struct {
+	world *World
+}
+
+// NewInspector creates inspector of world, it can be served with http.ListenAndServe
+func NewInspector(world *World) *Inspector {
+	return &Inspector{world: world}
+}
+
+type mapSummary struct {
+	ID         uint8              `json:"id"`
+	Name       string             `json:"name"`
+	Tick       uint64             `json:"tick"`
+	Entities   int                `json:"entities"`
+	Components []componentSummary `json:"components"`
+	Systems    []ecs.SystemInfo   `json:"systems"`
+}
+
+type componentSummary struct {
+	Name     string `json:"name"`
+	Tag      bool   `json:"tag,omitempty"`
+	Entities int    `json:"entities"`
+}
+
+type entityDump struct {
+	ID         uint64                     `json:"id"`
+	Parent     uint64                     `json:"parent,omitempty"`
+	Children   []uint64                   `json:"children,omitempty"`
+	Components map[string]json.RawMessage `json:"components"`
+	Tags       []string                   `json:"tags,omitempty"`
+	Relations  []relationDump             `json:"relations,omitempty"`
+}
+
+type relationDump struct {
+	Kind   ecs.RelationKind `json:"kind"`
+	Target uint64           `json:"target"`
+	Data   string           `json:"data,omitempty"`
+}
+
+func (i *Inspector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "maps" {
+		http.NotFound(w, r)
+		return
+	}
+	if len(parts) == 1 {
+		i.get(w, r, func() (interface{}, error) {
+			summaries := []mapSummary{}
+			for _, m := range i.world.Maps() {
+				summary, err := m.inspect(r.Context(), m.summary)
+				if err != nil {
+					return nil, err
+				}
+				summaries = append(summaries, summary.(mapSummary))
+			}
+			return summaries, nil
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(parts[1], 10, 8)
+	m := i.world.Map(uint8(id))
+	if err != nil || m == nil {
+		http.Error(w, fmt.Sprintf("map %v doesn't exist", parts[1]), http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 2:
+		i.get(w, r, func() (interface{}, error) {
+			return m.inspect(r.Context(), m.summary)
+		})
+	case len(parts) == 3 && parts[2] == "entities":
+		i.get(w, r, func() (interface{}, error) {
+			return m.inspect(r.Context(), func() (interface{}, error) {
+				ids := []uint64{}
+				for _, e := range m.manager.Query() {
+					ids = append(ids, e.ID())
+				}
+				return ids, nil
+			})
+		})
+	case len(parts) == 4 && parts[2] == "entities":
+		i.get(w, r, func() (interface{}, error) {
+			id, err := strconv.ParseUint(parts[3], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid entity id %v", parts[3])
+			}
+			return m.inspect(r.Context(), func() (interface{}, error) {
+				return m.dumpEntity(id)
+			})
+		})
+	case len(parts) == 4 && parts[2] == "systems":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+		if err != nil {
+			http.Error(w, "enabled must be true or false", http.StatusBadRequest)
+			return
+		}
+		if err := m.manager.EnableSystem(parts[3], enabled); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// get writes result of f as JSON
+func (i *Inspector) get(w http.ResponseWriter, r *http.Request, f func() (interface{}, error)) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	result, err := f()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+type inspectResult struct {
+	value interface{}
+	err   error
+}
+
+// inspect runs f between ticks of running map, or immediately if main loop is stopped, so
+// map must not be updated concurrently by its owner then. Main loop isn't blocked from
+// stopping while waiting. Result of f is passed through channel, so f running after
+// request was cancelled doesn't write into abandoned variables.
+func (m *Map) inspect(ctx context.Context, f func() (interface{}, error)) (interface{}, error) {
+	m.loopLock.Lock()
+	stop := m.stop
+	m.loopLock.Unlock()
+	if stop == nil {
+		return f()
+	}
+
+	results := make(chan inspectResult, 1)
+	m.RunBetweenTicks(func() {
+		value, err := f()
+		results <- inspectResult{value, err}
+	})
+	select {
+	case result := <-results:
+		return result.value, result.err
+	case <-stop:
+		return nil, fmt.Errorf("map %v was stopped", m.id)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *Map) summary() (interface{}, error) {
+	summary := mapSummary{
+		ID:       m.id,
+		Name:     m.name,
+		Tick:     m.manager.Tick(),
+		Entities: len(m.manager.Query()),
+		Systems:  m.manager.SystemInfos(),
+	}
+	for _, t := range m.manager.ComponentTypes() {
+		summary.Components = append(summary.Components, componentSummary{
+			Name:     m.manager.ComponentName(t),
+			Tag:      m.manager.IsTag(t),
+			Entities: len(m.manager.Query(ecs.With(t))),
+		})
+	}
+	sort.Slice(summary.Components, func(i, j int) bool {
+		return summary.Components[i].Name < summary.Components[j].Name
+	})
+	return summary, nil
+}
+
+func (m *Map) dumpEntity(id uint64) (entityDump, error) {
+	e, ok := m.manager.Entity(id)
+	if !ok {
+		return entityDump{}, fmt.Errorf("entity %v doesn't exist on map %v", id, m.id)
+	}
+	dump := entityDump{ID: id, Components: make(map[string]json.RawMessage)}
+	if parent := e.Parent(); parent != nil {
+		dump.Parent = parent.ID()
+	}
+	for _, child := range e.Children() {
+		dump.Children = append(dump.Children, child.ID())
+	}
+	for _, c := range e.GetComponents() {
+		name := m.manager.ComponentName(reflect.TypeOf(c))
+		if m.manager.IsTag(reflect.TypeOf(c)) {
+			dump.Tags = append(dump.Tags, name)
+			continue
+		}
+		data, err := json.Marshal(c)
+		if err != nil {
+			return entityDump{}, fmt.Errorf("failed to dump component %T: %v", c, err)
+		}
+		dump.Components[name] = data
+	}
+	sort.Strings(dump.Tags)
+	for _, r := range m.manager.AllRelations(e) {
+		dump.Relations = append(dump.Relations, relationDump{Kind: r.Kind, Target: r.Target.ID(), Data: r.Data})
+	}
+	return dump, nil
+}
@@ -0,0 +1,115 @@
+package world
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/component"
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+func newInspectedWorld(t *testing.T) (*World, *ecs.Entity, *httptest.Server) {
+	w := NewWorld(Config{})
+	m, err := w.CreateMap(1, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := ecs.NewEntity(m.Manager()).
+		AddComponent(component.NewGUID("inspected")).
+		AddComponent(component.MapItem{MapID: 1, Position: coord.Position{X: 1, Y: 1, Z: 1}}).
+		AddComponent(component.Player{}).
+		Register()
+	m.Update(0)
+
+	w.Start(context.Background())
+	server := httptest.NewServer(NewInspector(w))
+	t.Cleanup(func() {
+		server.Close()
+		if err := w.Stop(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+	return w, e, server
+}
+
+func getJSON(t *testing.T, url string, result interface{}) {
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("GET %v returned %v", url, response.Status)
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInspectorMaps(t *testing.T) {
+	_, _, server := newInspectedWorld(t)
+
+	var summaries []mapSummary
+	getJSON(t, server.URL+"/maps", &summaries)
+	if len(summaries) != 1 || summaries[0].ID != 1 || summaries[0].Entities != 1 {
+		t.Fatalf("unexpected summaries %+v", summaries)
+	}
+	if len(summaries[0].Systems) == 0 || len(summaries[0].Components) == 0 {
+		t.Fatalf("expected systems and components in summary %+v", summaries[0])
+	}
+
+	response, err := http.Get(server.URL + "/maps/9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected missing map to return 404, got %v", response.Status)
+	}
+}
+
+func TestInspectorEntityDump(t *testing.T) {
+	_, e, server := newInspectedWorld(t)
+
+	var dump entityDump
+	getJSON(t, fmt.Sprintf("%v/maps/1/entities/%v", server.URL, e.ID()), &dump)
+	if dump.ID != e.ID() || string(dump.Components["guid"]) != `"inspected"` {
+		t.Fatalf("unexpected dump %+v", dump)
+	}
+	if len(dump.Tags) != 1 || dump.Tags[0] != "player" {
+		t.Fatalf("expected player tag, got %v", dump.Tags)
+	}
+}
+
+func TestInspectorSystemToggle(t *testing.T) {
+	w, _, server := newInspectedWorld(t)
+
+	url := server.URL + "/maps/1/systems/*system.MovementSystem?enabled=false"
+	response, err := http.Post(url, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected system to be disabled, got %v", response.Status)
+	}
+	for _, info := range w.Map(1).Manager().SystemInfos() {
+		if info.Name == "*system.MovementSystem" && info.Enabled {
+			t.Fatal("expected movement system to be disabled")
+		}
+	}
+
+	response, err = http.Post(server.URL+"/maps/1/systems/unknown?enabled=false", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected unknown system to return 404, got %v", response.Status)
+	}
+}
@@ -0,0 +1,188 @@
+package world
+
+import (
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+// MaxLight is light level of tiles exposed to sky
+const MaxLight = 15
+
+// lighting computes light levels from sky exposure and light emitting tiles. Light
+// spreads only through loaded chunks, losing one level per tile, and flows into
+// chunks when they are loaded.
+type lighting struct {
+	m *Map
+}
+
+type lightRemoval struct {
+	p     coord.Position
+	level uint8
+}
+
+// LightAt returns light level of tile, 0 if its chunk isn't loaded
+func (m *Map) LightAt(p coord.Position) uint8 {
+	ch, l, ok := m.loadedChunkAt(p)
+	if !ok {
+		return 0
+	}
+	return ch.GetLight(l)
+}
+
+// peek returns loaded chunk containing tile, lighting never loads chunks by itself
+func (l *lighting) peek(p coord.Position) (*Chunk, coord.LocalPosition, bool) {
+	d := l.m.dimensions
+	if p.Z < 0 || p.Z >= d.ChunkHeight {
+		return nil, coord.LocalPosition{}, false
+	}
+	ch := l.m.globalChunkManager.PeekChunk(d.ToChunk(p))
+	if ch == nil {
+		return nil, coord.LocalPosition{}, false
+	}
+	return ch, d.ToLocal(p), true
+}
+
+func (l *lighting) get(p coord.Position) uint8 {
+	if ch, lp, ok := l.peek(p); ok {
+		return ch.GetLight(lp)
+	}
+	return 0
+}
+
+func (l *lighting) set(p coord.Position, level uint8, modified bool) {
+	if ch, lp, ok := l.peek(p); ok {
+		ch.setLight(lp, level)
+		ch.dirty = ch.dirty || modified
+	}
+}
+
+// source returns light emitted by tile itself, either from sky or from tile declaration
+func (l *lighting) source(p coord.Position) uint8 {
+	ch, lp, ok := l.peek(p)
+	if !ok {
+		return 0
+	}
+	t := ch.GetTile(lp)
+	if !t.IsTransparent() {
+		return t.Light
+	}
+	for z := lp.Z + 1; z < ch.height; z++ {
+		if !ch.GetTile(coord.LocalPosition{X: lp.X, Y: lp.Y, Z: z}).IsTransparent() {
+			return t.Light
+		}
+	}
+	return MaxLight
+}
+
+// propagate spreads light from queued tiles breadth first
+func (l *lighting) propagate(queue []coord.Position, modified bool) {
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		level := l.get(p)
+		if level <= 1 {
+			continue
+		}
+		for _, n := range p.Neighbors(coord.Neighbors6) {
+			ch, lp, ok := l.peek(n)
+			if !ok || !ch.GetTile(lp).IsTransparent() || ch.GetLight(lp) >= level-1 {
+				continue
+			}
+			ch.setLight(lp, level-1)
+			ch.dirty = ch.dirty || modified
+			queue = append(queue, n)
+		}
+	}
+}
+
+// chunkLoaded lights chunk which was generated or saved without light, and
+// exchanges light with already loaded neighbors
+func (l *lighting) chunkLoaded(ch *Chunk) {
+	d := l.m.dimensions
+	var queue []coord.Position
+
+	for x := 0; x < ch.width; x++ {
+		for y := 0; y < ch.width; y++ {
+			border := x == 0 || y == 0 || x == ch.width-1 || y == ch.width-1
+			for z := 0; z < ch.height; z++ {
+				lp := coord.LocalPosition{X: x, Y: y, Z: z}
+				p := d.ToGlobal(ch.position, lp)
+				if !ch.lit {
+					ch.setLight(lp, l.source(p))
+				}
+				if ch.GetLight(lp) > 1 && (!ch.lit || border) {
+					queue = append(queue, p)
+				}
+				if !border {
+					continue
+				}
+				for _, n := range p.Neighbors(coord.Neighbors4) {
+					if d.ToChunk(n) != ch.position && l.get(n) > 1 {
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+	}
+	ch.lit = true
+	l.propagate(queue, false)
+}
+
+// tileChanged updates light around changed tile and of tiles below it, which
+// could gain or lose sky exposure
+func (l *lighting) tileChanged(event TileChanged) {
+	if event.OldTile == event.NewTile {
+		return
+	}
+	positions := []coord.Position{event.Position}
+	for z := event.Position.Z - 1; z >= 0; z-- {
+		positions = append(positions, coord.Position{X: event.Position.X, Y: event.Position.Y, Z: z})
+	}
+	l.update(positions)
+}
+
+// update removes light which could come from changed tiles, then lights area again
+// from remaining sources
+func (l *lighting) update(positions []coord.Position) {
+	var removals []lightRemoval
+	var removed []coord.Position
+	var relight []coord.Position
+
+	for _, p := range positions {
+		if level := l.get(p); level > 0 {
+			l.set(p, 0, true)
+			removals = append(removals, lightRemoval{p, level})
+			removed = append(removed, p)
+		}
+	}
+
+	for len(removals) > 0 {
+		r := removals[0]
+		removals = removals[1:]
+		for _, n := range r.p.Neighbors(coord.Neighbors6) {
+			level := l.get(n)
+			if level == 0 {
+				continue
+			}
+			if level < r.level {
+				l.set(n, 0, true)
+				removals = append(removals, lightRemoval{n, level})
+				removed = append(removed, n)
+			} else {
+				relight = append(relight, n)
+			}
+		}
+	}
+
+	for _, p := range append(removed, positions...) {
+		if s := l.source(p); s > l.get(p) {
+			l.set(p, s, true)
+			relight = append(relight, p)
+		}
+	}
+	// tiles which became transparent are lit by their neighbors
+	for _, p := range positions {
+		relight = append(relight, p.Neighbors(coord.Neighbors6)...)
+	}
+	l.propagate(relight, true)
+}
@@ -0,0 +1,78 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+// roofGenerator covers every chunk with dirt at its top level
+type roofGenerator struct{}
+
+func (roofGenerator) Generate(ch *Chunk) {
+	for x := 0; x < ch.width; x++ {
+		for y := 0; y < ch.width; y++ {
+			ch.tiles[ch.tileIndex(coord.LocalPosition{X: x, Y: y, Z: ch.height - 1})] = 1
+		}
+	}
+}
+
+func expectLight(t *testing.T, m *Map, p coord.Position, level uint8) {
+	t.Helper()
+	if light := m.LightAt(p); light != level {
+		t.Fatalf("expected light %v at %v, got %v", level, p, light)
+	}
+}
+
+func TestTorchAndRoofLighting(t *testing.T) {
+	w := NewWorld(Config{ChunkWidth: 16, ChunkHeight: 4, Generator: roofGenerator{}})
+	m, _ := w.CreateMap(1, 1, 1)
+	m.GetChunk(coord.ChunkPosition{})
+	p, n := coord.Position{X: 8, Y: 8, Z: 1}, coord.Position{X: 9, Y: 8, Z: 1}
+	expectLight(t, m, p, 0)
+
+	m.SetTile(p, 5)
+	expectLight(t, m, p, 14)
+	expectLight(t, m, n, 13)
+
+	m.SetTile(p, 0)
+	expectLight(t, m, p, 0)
+	expectLight(t, m, n, 0)
+
+	hole := coord.Position{X: 8, Y: 8, Z: 3}
+	m.SetTile(hole, 0)
+	expectLight(t, m, hole, MaxLight)
+	expectLight(t, m, p, MaxLight)
+	expectLight(t, m, n, MaxLight-1)
+
+	m.SetTile(hole, 1)
+	expectLight(t, m, p, 0)
+	expectLight(t, m, n, 0)
+}
+
+func TestRelightAcrossChunkBorder(t *testing.T) {
+	w := NewWorld(Config{ChunkWidth: 16, ChunkHeight: 4, Generator: roofGenerator{}})
+	m, _ := w.CreateMap(1, 2, 1)
+	m.GetChunk(coord.ChunkPosition{X: 0})
+	torch := coord.Position{X: 15, Y: 8, Z: 1}
+	across := coord.Position{X: 16, Y: 8, Z: 1}
+
+	m.SetTile(torch, 5)
+	expectLight(t, m, across, 0)
+	// light flows into chunk when it is loaded
+	m.GetChunk(coord.ChunkPosition{X: 1})
+	expectLight(t, m, across, 13)
+	expectLight(t, m, coord.Position{X: 17, Y: 8, Z: 1}, 12)
+
+	m.SetTile(torch, 0)
+	expectLight(t, m, across, 0)
+
+	hole := coord.Position{X: 15, Y: 8, Z: 3}
+	m.SetTile(hole, 0)
+	expectLight(t, m, torch, MaxLight)
+	expectLight(t, m, across, MaxLight-1)
+
+	m.SetTile(hole, 1)
+	expectLight(t, m, torch, 0)
+	expectLight(t, m, across, 0)
+}
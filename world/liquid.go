@@ -0,0 +1,200 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+	"github.com/Tomislaw/far-worlds/world/tile"
+)
+
+// MaxLiquidLevel is level of tile fully filled with liquid
+const MaxLiquidLevel = 8
+
+// LiquidLevelField is name of metadata field holding level of liquid tiles
+const LiquidLevelField = "level"
+
+// DeepLiquidLevel is level from which liquid can't be walked through
+const DeepLiquidLevel = MaxLiquidLevel/2 + 1
+
+// liquidTickInterval is time in seconds between liquid simulation steps
+const liquidTickInterval = 0.25
+
+// LiquidSystem simulates liquid tiles flowing down and sideways. Only cells which
+// changed recently are processed, cells which didn't change during step are settled.
+type LiquidSystem struct {
+	m       *Map
+	elapsed float32
+	active  map[coord.Position]bool
+}
+
+func newLiquidSystem(m *Map) *LiquidSystem {
+	return &LiquidSystem{m: m, active: make(map[coord.Position]bool)}
+}
+
+func (s *LiquidSystem) Priority() int { return 100 }
+
+func (s *LiquidSystem) Remove(entity *ecs.Entity) {}
+
+func (s *LiquidSystem) Update(dt float32) {
+	s.elapsed += dt
+	if s.elapsed < liquidTickInterval {
+		return
+	}
+	s.elapsed = 0
+	s.Step()
+}
+
+// Active returns count of cells which will be processed in next step
+func (s *LiquidSystem) Active() int {
+	return len(s.active)
+}
+
+// Step processes active cells in deterministic order, cells changed during step
+// are processed in next one
+func (s *LiquidSystem) Step() {
+	cells := make([]coord.Position, 0, len(s.active))
+	for p := range s.active {
+		cells = append(cells, p)
+	}
+	s.active = make(map[coord.Position]bool)
+
+	sort.Slice(cells, func(i, j int) bool {
+		a, b := cells[i], cells[j]
+		if a.Z != b.Z {
+			return a.Z < b.Z
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	for _, p := range cells {
+		s.flow(p)
+	}
+}
+
+// validateLiquids returns error if liquid tile of atlas has no int level field, which is
+// required by simulation
+func validateLiquids(atlas *tile.TileAtlas) error {
+	for _, t := range atlas.Tiles {
+		if !t.Liquid {
+			continue
+		}
+		if field, ok := t.Field(LiquidLevelField); !ok || field.Type != tile.MetadataInt {
+			return fmt.Errorf("liquid tile %v has no int metadata field %q", t.Name, LiquidLevelField)
+		}
+	}
+	return nil
+}
+
+// chunkLoaded activates liquid cells of loaded chunk and cells of neighbors next to it,
+// so liquid which stopped at unloaded chunk or was saved while flowing continues to flow
+func (s *LiquidSystem) chunkLoaded(ch *Chunk) {
+	d := s.m.dimensions
+	for x := 0; x < ch.width; x++ {
+		for y := 0; y < ch.width; y++ {
+			border := x == 0 || y == 0 || x == ch.width-1 || y == ch.width-1
+			for z := 0; z < ch.height; z++ {
+				lp := coord.LocalPosition{X: x, Y: y, Z: z}
+				p := d.ToGlobal(ch.position, lp)
+				if ch.GetTile(lp).Liquid {
+					s.active[p] = true
+				}
+				if !border {
+					continue
+				}
+				for _, n := range p.Neighbors(coord.Neighbors4) {
+					if t, ok := s.m.loadedTile(n); ok && t.Liquid && d.ToChunk(n) != ch.position {
+						s.active[n] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// tileChanged activates changed liquid cells and their neighbors
+func (s *LiquidSystem) tileChanged(event TileChanged) {
+	if !tile.Atlas.Tiles[event.OldTile].Liquid && !tile.Atlas.Tiles[event.NewTile].Liquid {
+		return
+	}
+	s.active[event.Position] = true
+	for _, n := range event.Position.Neighbors(coord.Neighbors6) {
+		s.active[n] = true
+	}
+}
+
+func (s *LiquidSystem) flow(p coord.Position) {
+	liquid, ok := s.m.loadedTile(p)
+	if !ok || !liquid.Liquid {
+		return
+	}
+	level := s.m.LiquidLevel(p)
+	initial := level
+
+	below := p.Add(coord.Position{Z: -1})
+	if s.canFlowInto(below, liquid) {
+		belowLevel := s.m.LiquidLevel(below)
+		transfer := minInt(level, MaxLiquidLevel-belowLevel)
+		if transfer > 0 {
+			s.setLevel(below, liquid, belowLevel+transfer)
+			level -= transfer
+		}
+	}
+
+	for _, n := range p.Neighbors(coord.Neighbors4) {
+		if level <= 1 {
+			break
+		}
+		if !s.canFlowInto(n, liquid) {
+			continue
+		}
+		if neighborLevel := s.m.LiquidLevel(n); neighborLevel < level-1 {
+			s.setLevel(n, liquid, neighborLevel+1)
+			level--
+		}
+	}
+
+	if level != initial {
+		s.setLevel(p, liquid, level)
+	}
+}
+
+// canFlowInto returns true if tile is empty or contains the same liquid, liquids
+// never flow into unloaded chunks
+func (s *LiquidSystem) canFlowInto(p coord.Position, liquid tile.Tile) bool {
+	t, ok := s.m.loadedTile(p)
+	return ok && (t.Id == 0 || t.Id == liquid.Id)
+}
+
+func (s *LiquidSystem) setLevel(p coord.Position, liquid tile.Tile, level int) {
+	if level <= 0 {
+		s.m.SetTile(p, 0)
+		return
+	}
+	var err error
+	if s.m.GetTile(p).Id != liquid.Id {
+		err = s.m.SetTileWithMetadata(p, uint8(liquid.Id), TileMetadata{LiquidLevelField: level})
+	} else {
+		err = s.m.SetMetadata(p, LiquidLevelField, level)
+	}
+	if err != nil {
+		panic("Liquid tile " + liquid.Name + " has no level field: " + err.Error())
+	}
+}
+
+// LiquidLevel returns level of liquid in tile, 0 if tile isn't liquid
+func (m *Map) LiquidLevel(p coord.Position) int {
+	if !m.GetTile(p).Liquid {
+		return 0
+	}
+	level, _ := m.GetMetadata(p, LiquidLevelField).(int)
+	return level
+}
+
+// Liquids returns liquid simulation of map
+func (m *Map) Liquids() *LiquidSystem {
+	return m.liquids
+}
@@ -0,0 +1,109 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+	"github.com/Tomislaw/far-worlds/world/tile"
+)
+
+func TestSetLevelReportsOneChange(t *testing.T) {
+	w := NewWorld(Config{})
+	m, _ := w.CreateMap(1, 2, 2)
+	water := tile.Atlas.Tiles[6]
+	var events []TileChanged
+	m.OnTileChanged(func(event TileChanged) { events = append(events, event) })
+
+	p := coord.Position{X: 1, Y: 1, Z: 1}
+	m.liquids.setLevel(p, water, 3)
+	if len(events) != 1 || events[0].NewTile != uint8(water.Id) || events[0].Metadata[LiquidLevelField] != 3 {
+		t.Fatalf("expected one change to water with level 3, got %+v", events)
+	}
+	if level := m.LiquidLevel(p); level != 3 {
+		t.Fatalf("expected level 3, got %v", level)
+	}
+}
+
+func TestDeepLiquidIsNotPassable(t *testing.T) {
+	w := NewWorld(Config{})
+	m, _ := w.CreateMap(1, 2, 2)
+	e := addItem(m, "swimmer", coord.Position{X: 1, Y: 1, Z: 1})
+	m.Update(0)
+
+	from, to := coord.Position{X: 1, Y: 1, Z: 1}, coord.Position{X: 2, Y: 1, Z: 1}
+	m.liquids.setLevel(to, tile.Atlas.Tiles[6], DeepLiquidLevel)
+	if m.CanMove(e, from, to) {
+		t.Fatal("expected move into deep liquid to be rejected")
+	}
+	if _, found := m.FindPath(from, to, coord.Size{X: 1, Y: 1, Z: 1}, e.ID()); found {
+		t.Fatal("expected no path into deep liquid")
+	}
+
+	m.liquids.setLevel(to, tile.Atlas.Tiles[6], DeepLiquidLevel-1)
+	if !m.CanMove(e, from, to) {
+		t.Fatal("expected move into shallow liquid to be allowed")
+	}
+}
+
+func TestLiquidTilesNeedLevel(t *testing.T) {
+	atlas := &tile.TileAtlas{Tiles: []tile.Tile{{Name: "empty"}, {Name: "water", Liquid: true}}}
+	if err := validateLiquids(atlas); err == nil {
+		t.Fatal("expected liquid tile without level to be rejected")
+	}
+	if err := validateLiquids(&tile.Atlas); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLiquidFlowsIntoLoadedChunk(t *testing.T) {
+	dimensions := coord.Dimensions{ChunkWidth: 4, ChunkHeight: 2, MapWidth: 2, MapHeight: 1}
+	m, err := LoadMap(MapConfig{ID: 1, Dimensions: dimensions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	water := tile.Atlas.Tiles[6]
+	m.liquids.setLevel(coord.Position{X: 3, Y: 0, Z: 0}, water, MaxLiquidLevel)
+	for i := 0; i < 100 && m.liquids.Active() > 0; i++ {
+		m.liquids.Step()
+	}
+	edge := coord.Position{X: 4, Y: 0, Z: 0}
+	if m.PeekChunk(dimensions.ToChunk(edge)) != nil {
+		t.Fatal("expected liquid not to load neighbouring chunk")
+	}
+
+	m.GetChunk(dimensions.ToChunk(edge))
+	if m.liquids.Active() == 0 {
+		t.Fatal("expected liquid next to loaded chunk to be activated")
+	}
+	m.liquids.Step()
+	if m.LiquidLevel(edge) == 0 {
+		t.Fatal("expected liquid to flow into loaded chunk")
+	}
+}
+
+func TestLiquidActiveAfterReload(t *testing.T) {
+	storage := NewFileStorage(t.TempDir())
+	dimensions := coord.Dimensions{ChunkWidth: 4, ChunkHeight: 2, MapWidth: 1, MapHeight: 1}
+	m, err := LoadMap(MapConfig{ID: 1, Dimensions: dimensions, Storage: storage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := coord.Position{X: 1, Y: 1, Z: 0}
+	m.liquids.setLevel(p, tile.Atlas.Tiles[6], MaxLiquidLevel)
+	if err := m.Chunks().SaveAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadMap(MapConfig{ID: 1, Dimensions: dimensions, Storage: storage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded.GetChunk(dimensions.ToChunk(p))
+	if reloaded.liquids.Active() == 0 {
+		t.Fatal("expected saved liquid to be active after reload")
+	}
+	reloaded.liquids.Step()
+	if reloaded.LiquidLevel(p) == MaxLiquidLevel {
+		t.Fatal("expected reloaded liquid to continue flowing")
+	}
+}
@@ -1,62 +1,392 @@
 package world
 
 import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/Tomislaw/far-worlds/component"
 	"github.com/Tomislaw/far-worlds/ecs"
 	"github.com/Tomislaw/far-worlds/system"
+	"github.com/Tomislaw/far-worlds/world/coord"
+	"github.com/Tomislaw/far-worlds/world/tile"
 )
 
-const mapWidth = 8
+// MapConfig contains settings of single map
+type MapConfig struct {
+	// ID of map, referenced by MapItem.MapID
+	ID         uint8
+	Dimensions coord.Dimensions
+	// Name is used as prefix of keys in storage, defaults to map-<id>
+	Name string
+	// Storage used to load and save chunks, may be nil
+	Storage Storage
+	// Generator used to fill chunks which were never saved, may be nil
+	Generator ChunkGenerator
+	// ChunkIdleTimeout is time after which chunk without interest is unloaded
+	ChunkIdleTimeout time.Duration
+	// Prefabs are templates used by Manager.Spawn, may be nil
+	Prefabs *ecs.Prefabs
+}
 
 type Map struct {
+	id                 uint8
+	name               string
+	storage            Storage
 	globalChunkManager GlobalChunksManager
-	chunk              [mapWidth][mapWidth]Chunk
-	manager            ecs.Manager
+	dimensions         coord.Dimensions
+	spatial            *SpatialIndex
+	occupancy          *Occupancy
+	lighting           *lighting
+	liquids            *LiquidSystem
+	manager            *ecs.Manager
+
+	tileListeners []func(TileChanged)
+
+	bus             *MessageBus
+	messageHandlers map[reflect.Type][]func(Message)
+	clock           *Clock
 
-	mainLoopActive bool
+	// pending functions are run between ticks
+	pendingLock sync.Mutex
+	pending     []func()
+
+	loopLock sync.Mutex
+	stop     chan struct{}
+	done     chan struct{}
 
 	time int64
 }
 
-func (m *Map) GetChunk(x uint16, y uint16) *Chunk {
-	return &m.chunk[x][y]
+// ID returns id of map
+func (m *Map) ID() uint8 {
+	return m.id
+}
+
+// GetChunk returns chunk or nil if chunk is outside of map.
+// Chunks are loaded by chunk manager when accessed for the first time.
+func (m *Map) GetChunk(c coord.ChunkPosition) *Chunk {
+	return m.globalChunkManager.GetChunk(c)
 }
 
-type GlobalChunksManager interface {
-	GetChunk(x uint16, y uint16) *Chunk
+// PeekChunk returns chunk only if it's already loaded, otherwise nil. Queries of tiles use
+// it, so reading map never loads or generates chunks.
+func (m *Map) PeekChunk(c coord.ChunkPosition) *Chunk {
+	return m.globalChunkManager.PeekChunk(c)
 }
 
-func LoadMap() Map {
-	m := Map{}
+// Chunks returns chunk manager of map
+func (m *Map) Chunks() GlobalChunksManager {
+	return m.globalChunkManager
+}
+
+// LoadMap creates map, map with zero width or height is unbounded along that axis
+func LoadMap(config MapConfig) (*Map, error) {
+	if err := config.Dimensions.ValidateSize(); err != nil {
+		return nil, err
+	}
+	if err := validateLiquids(&tile.Atlas); err != nil {
+		return nil, err
+	}
+
+	if config.Name == "" {
+		config.Name = fmt.Sprintf("map-%v", config.ID)
+	}
+
+	m := &Map{
+		id:         config.ID,
+		name:       config.Name,
+		storage:    config.Storage,
+		manager:    ecs.NewManager(),
+		dimensions: config.Dimensions,
+		spatial:    NewSpatialIndex(config.Dimensions),
+		occupancy:  NewOccupancy(),
+	}
+	chunks := NewChunkManager(config.Dimensions, config.Name, config.Storage, config.Generator, config.ChunkIdleTimeout)
+	chunks.onTileChanged = m.emitTileChanged
+	m.globalChunkManager = chunks
+
+	m.lighting = &lighting{m}
+	chunks.onChunkLoaded = func(ch *Chunk) {
+		m.lighting.chunkLoaded(ch)
+		m.liquids.chunkLoaded(ch)
+	}
+	m.OnTileChanged(m.lighting.tileChanged)
+
+	m.manager.AddResource(m)
+	m.manager.AddResource(&tile.Atlas)
+	m.manager.AddResource(&tile.Materials)
+	m.manager.AddResource(rand.New(rand.NewSource(time.Now().UnixNano())))
+	m.manager.SetResource(system.NavigatorType, m)
+	if config.Prefabs != nil {
+		m.manager.AddResource(config.Prefabs)
+	}
+
+	component.RegisterComponents(m.manager)
+	system.RegisterSystems(m.manager)
+
+	// tile changes are also readable by systems as events
+	m.manager.RegisterEvent(reflect.TypeOf(TileChanged{}))
+	m.OnTileChanged(func(event TileChanged) { m.manager.Emit(event) })
+
+	m.manager.Observe(component.Type.MapItem, mapItemObserver{m})
+	m.manager.Observe(component.Type.ChunkLoader, chunkLoaderObserver{m})
+	m.manager.Observe(component.Type.MapItemBlock, blockObserver{m})
+	m.manager.Observe(component.Type.Attached, attachedObserver{m})
+	m.manager.ObserveHierarchy(attachedObserver{m})
+
+	m.OnMessage(reflect.TypeOf(EntityTransfer{}), m.receiveEntity)
 
-	component.RegisterComponents(&m.manager)
-	system.RegisterSystems(&m.manager)
+	// clock events are broadcasted to every map, systems read them as events
+	m.manager.RegisterEvent(reflect.TypeOf(ClockEvent{}))
+	m.OnMessage(reflect.TypeOf(ClockEvent{}), func(message Message) { m.manager.Emit(message.Payload) })
 
-	return m
+	m.liquids = newLiquidSystem(m)
+	m.manager.RegisterSystem(m.liquids)
+	m.OnTileChanged(m.liquids.tileChanged)
+
+	if err := m.loadEntities(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Entities returns spatial index of entities on map, it's updated when MapItem components change
+func (m *Map) Entities() *SpatialIndex {
+	return m.spatial
+}
+
+// Occupancy returns tiles covered by footprints of entities with MapItemBlock
+func (m *Map) Occupancy() *Occupancy {
+	return m.occupancy
+}
+
+// Manager returns ecs manager of map
+func (m *Map) Manager() *ecs.Manager {
+	return m.manager
+}
+
+// Clock returns in-game clock of world, nil if map doesn't belong to world
+func (m *Map) Clock() *Clock {
+	return m.clock
+}
+
+// Dimensions returns size of map and its chunks
+func (m *Map) Dimensions() coord.Dimensions {
+	return m.dimensions
+}
+
+// chunkAt returns chunk containing tile and position of tile inside it, chunk is loaded if
+// needed, so it's used only for changes
+func (m *Map) chunkAt(p coord.Position) (*Chunk, coord.LocalPosition, error) {
+	if err := m.dimensions.Validate(p); err != nil {
+		return nil, coord.LocalPosition{}, err
+	}
+	return m.GetChunk(m.dimensions.ToChunk(p)), m.dimensions.ToLocal(p), nil
+}
+
+// loadedChunkAt returns chunk containing tile only if it's loaded
+func (m *Map) loadedChunkAt(p coord.Position) (*Chunk, coord.LocalPosition, bool) {
+	if !m.dimensions.Contains(p) {
+		return nil, coord.LocalPosition{}, false
+	}
+	ch := m.PeekChunk(m.dimensions.ToChunk(p))
+	if ch == nil {
+		return nil, coord.LocalPosition{}, false
+	}
+	return ch, m.dimensions.ToLocal(p), true
+}
+
+// GetTile returns tile at global position, tiles outside of map or in chunks which aren't
+// loaded are empty
+func (m *Map) GetTile(p coord.Position) tile.Tile {
+	ch, l, ok := m.loadedChunkAt(p)
+	if !ok {
+		return tile.Atlas.Tiles[0]
+	}
+	return ch.GetTile(l)
+}
+
+// loadedTile returns tile only if its chunk is loaded
+func (m *Map) loadedTile(p coord.Position) (tile.Tile, bool) {
+	ch, l, ok := m.loadedChunkAt(p)
+	if !ok {
+		return tile.Tile{}, false
+	}
+	return ch.GetTile(l), true
+}
+
+// SetTile changes tile at global position, returns error if position is outside of map or
+// tile isn't declared in atlas
+func (m *Map) SetTile(p coord.Position, id uint8) error {
+	if !tile.Atlas.Contains(id) {
+		return fmt.Errorf("unknown tile %v", id)
+	}
+	ch, l, err := m.chunkAt(p)
+	if err != nil {
+		return err
+	}
+	ch.SetTile(l, id)
+	return nil
+}
+
+// SetTileWithMetadata changes tile at global position together with its metadata, so
+// listeners are notified once
+func (m *Map) SetTileWithMetadata(p coord.Position, id uint8, metadata TileMetadata) error {
+	ch, l, err := m.chunkAt(p)
+	if err != nil {
+		return err
+	}
+	return ch.SetTileWithMetadata(l, id, metadata)
+}
+
+// GetMetadata returns metadata value of tile at global position, nil if its chunk isn't loaded
+func (m *Map) GetMetadata(p coord.Position, name string) interface{} {
+	ch, l, ok := m.loadedChunkAt(p)
+	if !ok {
+		return nil
+	}
+	return ch.GetMetadata(l, name)
+}
+
+// SetMetadata sets metadata value of tile at global position
+func (m *Map) SetMetadata(p coord.Position, name string, value interface{}) error {
+	ch, l, err := m.chunkAt(p)
+	if err != nil {
+		return err
+	}
+	return ch.SetMetadata(l, name, value)
+}
+
+// OnTileChanged registers listener called when tile or tile metadata changes in any chunk of map
+func (m *Map) OnTileChanged(listener func(TileChanged)) {
+	m.tileListeners = append(m.tileListeners, listener)
+}
+
+func (m *Map) emitTileChanged(ch *Chunk, p coord.LocalPosition, old uint8) {
+	event := TileChanged{
+		Position: m.dimensions.ToGlobal(ch.position, p),
+		OldTile:  old,
+		NewTile:  ch.GetTileID(p),
+		Metadata: ch.GetTileMetadata(p),
+	}
+	for _, listener := range m.tileListeners {
+		listener(event)
+	}
+}
+
+// RunBetweenTicks schedules function to run on map goroutine before next update,
+// when no system is running. Safe for concurrent use.
+func (m *Map) RunBetweenTicks(f func()) {
+	m.pendingLock.Lock()
+	m.pending = append(m.pending, f)
+	m.pendingLock.Unlock()
+}
+
+// runPending runs functions queued by RunBetweenTicks, returns false if there were none
+func (m *Map) runPending() bool {
+	m.pendingLock.Lock()
+	pending := m.pending
+	m.pending = nil
+	m.pendingLock.Unlock()
+
+	for _, f := range pending {
+		f()
+	}
+	return len(pending) > 0
 }
 
 func (m *Map) Update(dt float32) {
+	m.runPending()
+	m.deliverMessages()
 	m.manager.Update(dt)
+	m.globalChunkManager.Update(time.Now())
 }
 
+// StartMainLoop starts updating map on its own goroutine
 func (m *Map) StartMainLoop() {
-	m.mainLoopActive = true
-	m.time = time.Now().Local().UnixNano()
-	go m.mainLoop()
+	m.loopLock.Lock()
+	defer m.loopLock.Unlock()
 
+	if m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	m.time = time.Now().Local().UnixNano()
+	go m.mainLoop(m.stop, m.done)
 }
 
+// StopMainLoop stops main loop and waits until current tick is finished
 func (m *Map) StopMainLoop() {
-	m.mainLoopActive = false
+	m.loopLock.Lock()
+	defer m.loopLock.Unlock()
+	m.stopMainLoop()
+}
+
+// shutdown stops main loop, finishes queued work and saves map, concurrent shutdowns are
+// saving one by one
+func (m *Map) shutdown() error {
+	m.loopLock.Lock()
+	defer m.loopLock.Unlock()
+	m.stopMainLoop()
+	drain([]*Map{m})
+	return m.Save()
+}
+
+// maxDrainRounds limits how many times queued work is processed when maps are stopped
+const maxDrainRounds = 16
+
+// drain runs pending functions and delivers messages of stopped maps until there is no more
+// work, so entities extracted by transfers are inserted into target maps before they're saved
+func drain(maps []*Map) {
+	busy := make([]bool, len(maps))
+	for round := 0; round < maxDrainRounds; round++ {
+		done := true
+		for i, m := range maps {
+			busy[i] = m.runPending()
+			done = done && !busy[i]
+		}
+		for i, m := range maps {
+			if m.deliverMessages() {
+				busy[i] = true
+				done = false
+			}
+		}
+		if done {
+			return
+		}
+	}
+	for i, m := range maps {
+		if busy[i] {
+			m.manager.Errorf("Map %v is still busy after %v rounds, remaining work isn't saved\n", m.id, maxDrainRounds)
+		}
+	}
+}
+
+func (m *Map) stopMainLoop() {
+	if m.stop == nil {
+		return
+	}
+	close(m.stop)
+	<-m.done
+	m.stop = nil
+	m.done = nil
 }
 
-func (m *Map) mainLoop() {
-	for m.mainLoopActive {
+func (m *Map) mainLoop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
 		newTime := time.Now().Local().UnixNano()
 		dt := newTime - m.time
-		m.manager.Update(float32(dt) / 1000000000)
+		m.Update(float32(dt) / 1000000000)
 		m.time = newTime
 	}
 }
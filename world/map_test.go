@@ -0,0 +1,60 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+func TestQueriesDontLoadChunks(t *testing.T) {
+	w := NewWorld(Config{})
+	m, _ := w.CreateMap(1, 2, 2)
+	p := coord.Position{X: 20, Y: 20, Z: 1}
+	c := m.Dimensions().ToChunk(p)
+	if m.PeekChunk(c) != nil {
+		t.Fatalf("expected chunk %v not to be loaded", c)
+	}
+
+	m.GetTile(p)
+	m.GetMetadata(p, LiquidLevelField)
+	m.LightAt(p)
+	m.Raycast(coord.Position{X: 0, Y: 0, Z: 1}, p)
+	m.FieldOfView(coord.Position{X: 0, Y: 0, Z: 1}, 32)
+	m.FindPath(coord.Position{X: 0, Y: 0, Z: 1}, p, coord.Size{X: 1, Y: 1, Z: 1}, 0)
+	if m.PeekChunk(c) != nil {
+		t.Fatalf("expected queries not to load chunk %v", c)
+	}
+
+	m.SetTile(p, 0)
+	if m.PeekChunk(c) == nil {
+		t.Fatalf("expected change to load chunk %v", c)
+	}
+}
+
+func TestLoadMapSizes(t *testing.T) {
+	invalid := []coord.Dimensions{
+		{ChunkWidth: 0, ChunkHeight: 4, MapWidth: 1, MapHeight: 1},
+		{ChunkWidth: 16, ChunkHeight: 0, MapWidth: 1, MapHeight: 1},
+		{ChunkWidth: 16, ChunkHeight: 4, MapWidth: -1, MapHeight: 1},
+	}
+	for _, dimensions := range invalid {
+		if _, err := LoadMap(MapConfig{ID: 1, Dimensions: dimensions}); err == nil {
+			t.Errorf("expected map of size %+v to be rejected", dimensions)
+		}
+	}
+
+	m, err := LoadMap(MapConfig{ID: 1, Dimensions: coord.Dimensions{ChunkWidth: 16, ChunkHeight: 4, MapWidth: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	far := coord.Position{X: 5, Y: -1000, Z: 1}
+	if err := m.SetTile(far, 1); err != nil {
+		t.Fatalf("expected map unbounded along y to accept %v: %v", far, err)
+	}
+	if m.GetTile(far).Id != 1 {
+		t.Fatalf("expected tile at %v to be changed", far)
+	}
+	if err := m.SetTile(coord.Position{X: 32, Y: 0, Z: 1}, 1); err == nil {
+		t.Fatal("expected map bounded along x to reject tile outside of it")
+	}
+}
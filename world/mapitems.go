@@ -0,0 +1,174 @@
+package world
+
+import (
+	"github.com/Tomislaw/far-worlds/component"
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+// mapItemObserver keeps spatial index and chunk interests in sync with MapItem components
+type mapItemObserver struct {
+	m *Map
+}
+
+func (o mapItemObserver) ComponentAdded(entity *ecs.Entity, c interface{}) {
+	o.m.spatial.Update(entity, c.(component.MapItem).Position)
+	o.m.updateInterest(entity)
+	o.m.updateFootprint(entity)
+	for _, child := range entity.Children() {
+		o.m.updateAttached(child)
+	}
+}
+
+func (o mapItemObserver) ComponentRemoved(entity *ecs.Entity) {
+	o.m.spatial.Remove(entity)
+	o.m.updateInterest(entity)
+	o.m.updateFootprint(entity)
+}
+
+// blockObserver keeps occupancy in sync with MapItemBlock components
+type blockObserver struct {
+	m *Map
+}
+
+func (o blockObserver) ComponentAdded(entity *ecs.Entity, c interface{}) {
+	o.m.updateFootprint(entity)
+}
+
+func (o blockObserver) ComponentRemoved(entity *ecs.Entity) {
+	o.m.updateFootprint(entity)
+}
+
+// attachedObserver moves attached entities to positions relative to their parents
+type attachedObserver struct {
+	m *Map
+}
+
+func (o attachedObserver) ComponentAdded(entity *ecs.Entity, c interface{}) {
+	o.m.updateAttached(entity)
+	o.m.updateFootprint(entity)
+}
+
+func (o attachedObserver) ComponentRemoved(entity *ecs.Entity) {
+	o.m.updateFootprint(entity)
+}
+
+func (o attachedObserver) ParentChanged(entity *ecs.Entity, oldParent *ecs.Entity) {
+	o.m.updateAttached(entity)
+}
+
+// updateAttached queues MapItem change of attached entity if its parent moved
+func (m *Map) updateAttached(entity *ecs.Entity) {
+	attached, ok := ecs.Get[component.Attached](entity)
+	if !ok || entity.Parent() == nil {
+		return
+	}
+	parent, ok := ecs.Get[component.MapItem](entity.Parent())
+	if !ok {
+		return
+	}
+	item := component.MapItem{Position: parent.Position.Add(attached.Offset), MapID: parent.MapID}
+	if current, ok := ecs.Get[component.MapItem](entity); !ok || current != item {
+		entity.AddComponent(item)
+	}
+}
+
+// chunkLoaderObserver pins chunks around entities with ChunkLoader
+type chunkLoaderObserver struct {
+	m *Map
+}
+
+func (o chunkLoaderObserver) ComponentAdded(entity *ecs.Entity, c interface{}) {
+	o.m.updateInterest(entity)
+}
+
+func (o chunkLoaderObserver) ComponentRemoved(entity *ecs.Entity) {
+	o.m.updateInterest(entity)
+}
+
+func (m *Map) updateInterest(entity *ecs.Entity) {
+	item, hasItem := ecs.Get[component.MapItem](entity)
+	loader, hasLoader := ecs.Get[component.ChunkLoader](entity)
+	if !hasItem || !hasLoader {
+		m.globalChunkManager.RemoveInterest(entity.ID())
+		return
+	}
+	m.globalChunkManager.SetInterest(entity.ID(), m.dimensions.ToChunk(item.Position), loader.Radius)
+}
+
+func (m *Map) updateFootprint(entity *ecs.Entity) {
+	item, hasItem := ecs.Get[component.MapItem](entity)
+	block, hasBlock := ecs.Get[component.MapItemBlock](entity)
+	if !hasItem || !hasBlock || ecs.Has[component.Attached](entity) {
+		m.occupancy.Remove(entity.ID())
+		return
+	}
+	m.occupancy.Place(entity.ID(), item.Position, block.Size())
+}
+
+// entitySize returns footprint of entity, entities without MapItemBlock take single tile
+func entitySize(entity *ecs.Entity) coord.Size {
+	if block, ok := ecs.Get[component.MapItemBlock](entity); ok {
+		return block.Size()
+	}
+	return coord.Size{X: 1, Y: 1, Z: 1}
+}
+
+// CanPlace returns true if box fits inside loaded chunks of map, doesn't overlap blocking
+// tiles and isn't occupied by entities other than ignored one
+func (m *Map) CanPlace(origin coord.Position, size coord.Size, ignore uint64) bool {
+	for _, p := range size.Box(origin) {
+		if t, ok := m.loadedTile(p); !ok || t.Block {
+			return false
+		}
+	}
+	return m.occupancy.IsAreaFree(origin, size, ignore)
+}
+
+// IsWalkable returns true if single tile is free for entity with ignored id
+func (m *Map) IsWalkable(p coord.Position, ignore uint64) bool {
+	return m.CanPlace(p, coord.Size{X: 1, Y: 1, Z: 1}, ignore)
+}
+
+// CanMove returns true if footprint of entity can step from tile to neighbouring tile, deep
+// liquid can't be entered
+func (m *Map) CanMove(entity *ecs.Entity, from coord.Position, to coord.Position) bool {
+	size := entitySize(entity)
+	return canStep(from, to, func(p coord.Position) bool {
+		return m.passable(p, size, entity.ID())
+	})
+}
+
+// passable returns true if footprint can stand at origin and origin isn't deep liquid. It's
+// shared by movement and pathfinding.
+func (m *Map) passable(origin coord.Position, size coord.Size, ignore uint64) bool {
+	if m.LiquidLevel(origin) >= DeepLiquidLevel {
+		return false
+	}
+	return m.CanPlace(origin, size, ignore)
+}
+
+// Move checks move like CanMove and reserves footprint of entity at destination, so other
+// entities moving in the same tick can't step into it before MapItem change is resolved
+func (m *Map) Move(entity *ecs.Entity, from coord.Position, to coord.Position) bool {
+	if !m.CanMove(entity, from, to) {
+		return false
+	}
+	if block, ok := ecs.Get[component.MapItemBlock](entity); ok && !ecs.Has[component.Attached](entity) {
+		m.occupancy.Place(entity.ID(), to, block.Size())
+	}
+	return true
+}
+
+// canStep returns true if footprint can enter neighbouring tile, diagonal steps can't cut
+// corners of tiles which footprint can't enter. It's shared by movement and pathfinding.
+func canStep(from coord.Position, to coord.Position, canEnter func(coord.Position) bool) bool {
+	if !canEnter(to) {
+		return false
+	}
+	d := to.Sub(from)
+	if d.X != 0 && d.Y != 0 {
+		return canEnter(from.Add(coord.Position{X: d.X})) && canEnter(from.Add(coord.Position{Y: d.Y}))
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/component"
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+func TestContestedTileTakenInIDOrder(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		w := NewWorld(Config{})
+		m, _ := w.CreateMap(1, 1, 1)
+		m.GetChunk(coord.ChunkPosition{})
+		target := coord.Position{X: 2, Y: 1, Z: 1}
+		var entities []*ecs.Entity
+		for _, x := range []int{1, 3} {
+			entities = append(entities, ecs.NewEntity(m.Manager()).
+				AddComponent(component.MapItem{MapID: 1, Position: coord.Position{X: x, Y: 1, Z: 1}}).
+				AddComponent(component.MapItemBlock{}).
+				AddComponent(component.MapItemMovement{Target: target}).
+				Register())
+		}
+		m.Update(0)
+		m.Update(1)
+		m.Update(0)
+
+		first, _ := ecs.Get[component.MapItem](entities[0])
+		second, _ := ecs.Get[component.MapItem](entities[1])
+		if first.Position != target || second.Position == target {
+			t.Fatalf("expected entity with lower id to take tile, got %v and %v", first.Position, second.Position)
+		}
+		if p, _ := m.Entities().Position(entities[0]); p != target {
+			t.Fatalf("expected spatial index to follow moved entity, got %v", p)
+		}
+	}
+}
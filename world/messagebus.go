@@ -0,0 +1,227 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Tomislaw/far-worlds/ecs"
+)
+
+// DefaultInboxSize is count of messages waiting for delivery after which new messages to map are dropped
+const DefaultInboxSize = 1024
+
+// WorldSender is sender of messages posted by world itself and not by any map
+const WorldSender = -1
+
+// ErrInboxFull is returned when message was dropped because target map didn't process its inbox yet
+var ErrInboxFull = errors.New("inbox full")
+
+// ErrMapRemoved is returned when message was dropped because target map was removed from world
+var ErrMapRemoved = errors.New("map removed")
+
+// Message is sent between maps, payload type is used to select handlers
+type Message struct {
+	// From is id of sending map or WorldSender
+	From int
+	// To is id of receiving map
+	To      uint8
+	Payload interface{}
+
+	sequence uint64
+}
+
+// ChatMessage is broadcasted to all maps as global chat
+type ChatMessage struct {
+	Sender string
+	Text   string
+}
+
+// WorldEvent is broadcasted to all maps to notify about world wide events
+type WorldEvent struct {
+	Name string
+	Data interface{}
+}
+
+// EntityTransfer moves entity with its components and descendants to another map
+type EntityTransfer struct {
+	Entity     *ecs.Entity
+	Components []interface{}
+	Children   []EntityTransfer
+	// Relations between entities of transferred tree, relations with other entities are
+	// dropped. Set only on root transfer.
+	Relations []ecs.Relation
+}
+
+// MessageMetrics contains counters of messages sent to map
+type MessageMetrics struct {
+	Posted    uint64 `json:"posted"`
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+	Unhandled uint64 `json:"unhandled"`
+}
+
+type inbox struct {
+	messages []Message
+	metrics  MessageMetrics
+	// closed inbox of removed map doesn't accept new messages
+	closed bool
+}
+
+// MessageBus delivers messages between map goroutines. Messages are queued in inbox of
+// receiving map and delivered at its tick boundary, ordered by sender and sending order,
+// so delivery doesn't depend on goroutine scheduling between senders.
+type MessageBus struct {
+	lock      sync.Mutex
+	capacity  int
+	sequences map[int]uint64
+	inboxes   map[uint8]*inbox
+}
+
+// NewMessageBus creates bus with inboxes holding up to capacity messages
+func NewMessageBus(capacity int) *MessageBus {
+	if capacity <= 0 {
+		capacity = DefaultInboxSize
+	}
+	return &MessageBus{
+		capacity:  capacity,
+		sequences: make(map[int]uint64),
+		inboxes:   make(map[uint8]*inbox),
+	}
+}
+
+func (b *MessageBus) inbox(id uint8) *inbox {
+	in, ok := b.inboxes[id]
+	if !ok {
+		in = &inbox{}
+		b.inboxes[id] = in
+	}
+	return in
+}
+
+// Post queues message to map, returns ErrInboxFull if message was dropped
+func (b *MessageBus) Post(from int, to uint8, payload interface{}) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.post(from, to, payload)
+}
+
+func (b *MessageBus) post(from int, to uint8, payload interface{}) error {
+	in := b.inbox(to)
+	atomic.AddUint64(&in.metrics.Posted, 1)
+	if in.closed {
+		atomic.AddUint64(&in.metrics.Dropped, 1)
+		return ErrMapRemoved
+	}
+	if len(in.messages) >= b.capacity {
+		atomic.AddUint64(&in.metrics.Dropped, 1)
+		return ErrInboxFull
+	}
+	b.sequences[from]++
+	in.messages = append(in.messages, Message{From: from, To: to, Payload: payload, sequence: b.sequences[from]})
+	return nil
+}
+
+// Broadcast queues message to all given maps, returns ErrInboxFull if any of them dropped it
+func (b *MessageBus) Broadcast(from int, to []uint8, payload interface{}) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var err error
+	for _, id := range to {
+		if e := b.post(from, id, payload); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// take removes all messages queued for map in delivery order
+func (b *MessageBus) take(id uint8) ([]Message, *MessageMetrics) {
+	b.lock.Lock()
+	in := b.inbox(id)
+	messages := in.messages
+	in.messages = nil
+	b.lock.Unlock()
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		if messages[i].From != messages[j].From {
+			return messages[i].From < messages[j].From
+		}
+		return messages[i].sequence < messages[j].sequence
+	})
+	return messages, &in.metrics
+}
+
+// open makes inbox of added map accept messages
+func (b *MessageBus) open(id uint8) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.inbox(id).closed = false
+}
+
+// close makes inbox of removed map reject new messages, messages which are already queued
+// can still be taken, so entities transferred to map aren't lost
+func (b *MessageBus) close(id uint8) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.inbox(id).closed = true
+}
+
+// Metrics returns counters of messages sent to map
+func (b *MessageBus) Metrics(id uint8) MessageMetrics {
+	b.lock.Lock()
+	in, ok := b.inboxes[id]
+	b.lock.Unlock()
+	if !ok {
+		return MessageMetrics{}
+	}
+
+	return MessageMetrics{
+		Posted:    atomic.LoadUint64(&in.metrics.Posted),
+		Delivered: atomic.LoadUint64(&in.metrics.Delivered),
+		Dropped:   atomic.LoadUint64(&in.metrics.Dropped),
+		Unhandled: atomic.LoadUint64(&in.metrics.Unhandled),
+	}
+}
+
+// OnMessage registers handler of messages with payload of given type, handlers are called
+// on map goroutine between ticks
+func (m *Map) OnMessage(payloadType reflect.Type, handler func(Message)) {
+	if m.messageHandlers == nil {
+		m.messageHandlers = make(map[reflect.Type][]func(Message))
+	}
+	m.messageHandlers[payloadType] = append(m.messageHandlers[payloadType], handler)
+}
+
+// Post sends message from this map to another map of the same world
+func (m *Map) Post(to uint8, payload interface{}) error {
+	if m.bus == nil {
+		return fmt.Errorf("map %v doesn't belong to world", m.id)
+	}
+	return m.bus.Post(int(m.id), to, payload)
+}
+
+// deliverMessages calls handlers of queued messages, returns false if there were none
+func (m *Map) deliverMessages() bool {
+	if m.bus == nil {
+		return false
+	}
+	messages, metrics := m.bus.take(m.id)
+	for _, message := range messages {
+		atomic.AddUint64(&metrics.Delivered, 1)
+		handlers := m.messageHandlers[reflect.TypeOf(message.Payload)]
+		if len(handlers) == 0 {
+			atomic.AddUint64(&metrics.Unhandled, 1)
+			fmt.Printf("Unhandled message %T from %v on map %v\n", message.Payload, message.From, m.id)
+			continue
+		}
+		for _, handler := range handlers {
+			handler(message)
+		}
+	}
+	return len(messages) > 0
+}
@@ -0,0 +1,102 @@
+package world
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMessagesOrderedBySender(t *testing.T) {
+	bus := NewMessageBus(0)
+	posts := []struct {
+		from    int
+		payload int
+	}{{3, 1}, {1, 2}, {WorldSender, 3}, {1, 4}, {3, 5}}
+	for _, post := range posts {
+		if err := bus.Post(post.from, 1, post.payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages, _ := bus.take(1)
+	expected := []struct {
+		from    int
+		payload int
+	}{{WorldSender, 3}, {1, 2}, {1, 4}, {3, 1}, {3, 5}}
+	if len(messages) != len(expected) {
+		t.Fatalf("expected %v messages, got %v", len(expected), len(messages))
+	}
+	for i, message := range messages {
+		if message.From != expected[i].from || message.Payload != expected[i].payload {
+			t.Errorf("expected message %v to be %+v, got %+v", i, expected[i], message)
+		}
+	}
+	if messages, _ := bus.take(1); len(messages) != 0 {
+		t.Fatalf("expected inbox to be empty after take, got %v", messages)
+	}
+}
+
+func TestFullInboxDropsMessages(t *testing.T) {
+	w := NewWorld(Config{InboxSize: 2})
+	m, _ := w.CreateMap(1, 1, 1)
+	w.CreateMap(2, 1, 1)
+	var received []ChatMessage
+	m.OnMessage(reflect.TypeOf(ChatMessage{}), func(message Message) {
+		received = append(received, message.Payload.(ChatMessage))
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := w.Map(2).Post(1, ChatMessage{Text: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Map(2).Post(1, ChatMessage{Text: "dropped"}); !errors.Is(err, ErrInboxFull) {
+		t.Fatalf("expected ErrInboxFull, got %v", err)
+	}
+	if err := w.Broadcast(WorldSender, ChatMessage{Text: "dropped"}); !errors.Is(err, ErrInboxFull) {
+		t.Fatalf("expected broadcast to report ErrInboxFull, got %v", err)
+	}
+	m.Update(0)
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 messages to be delivered, got %v", received)
+	}
+	metrics := w.Messages().Metrics(1)
+	if metrics != (MessageMetrics{Posted: 4, Delivered: 2, Dropped: 2}) {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+	if metrics := w.Messages().Metrics(2); metrics.Dropped != 0 {
+		t.Fatalf("expected broadcast to reach map with free inbox, got %+v", metrics)
+	}
+}
+
+func TestRemovedMapRejectsMessages(t *testing.T) {
+	w := NewWorld(Config{})
+	w.CreateMap(1, 1, 1)
+	m, _ := w.CreateMap(2, 1, 1)
+	delivered := 0
+	m.OnMessage(reflect.TypeOf(ChatMessage{}), func(Message) { delivered++ })
+
+	if err := w.Map(1).Post(2, ChatMessage{Text: "queued"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RemoveMap(2); err != nil {
+		t.Fatal(err)
+	}
+	if delivered != 1 {
+		t.Fatalf("expected message queued before removal to be delivered, got %v", delivered)
+	}
+	if err := w.Map(1).Post(2, ChatMessage{Text: "late"}); !errors.Is(err, ErrMapRemoved) {
+		t.Fatalf("expected ErrMapRemoved, got %v", err)
+	}
+	if metrics := w.Messages().Metrics(2); metrics.Dropped != 1 {
+		t.Fatalf("expected dropped message to be counted, got %+v", metrics)
+	}
+
+	if err := w.AddMap(m); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Map(1).Post(2, ChatMessage{Text: "again"}); err != nil {
+		t.Fatalf("expected map added again to accept messages, got %v", err)
+	}
+}
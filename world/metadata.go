@@ -0,0 +1,77 @@
+package world
+
+import (
+	"fmt"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+	"github.com/Tomislaw/far-worlds/world/tile"
+)
+
+// TileMetadata contains values of metadata fields declared by tile in tiles.json
+type TileMetadata map[string]interface{}
+
+// GetInt returns int value of field or 0 if not set
+func (md TileMetadata) GetInt(name string) int {
+	v, _ := md[name].(int)
+	return v
+}
+
+// GetFloat returns float value of field or 0 if not set
+func (md TileMetadata) GetFloat(name string) float64 {
+	v, _ := md[name].(float64)
+	return v
+}
+
+// GetBool returns bool value of field or false if not set
+func (md TileMetadata) GetBool(name string) bool {
+	v, _ := md[name].(bool)
+	return v
+}
+
+// GetString returns string value of field or empty string if not set
+func (md TileMetadata) GetString(name string) string {
+	v, _ := md[name].(string)
+	return v
+}
+
+func (md TileMetadata) copy() TileMetadata {
+	if md == nil {
+		return nil
+	}
+	c := make(TileMetadata, len(md))
+	for k, v := range md {
+		c[k] = v
+	}
+	return c
+}
+
+// normalize validates values against fields declared by tile and converts them
+// to declared types. Values equal to field defaults are left out, since metadata stores
+// only values which differ from defaults.
+func (md TileMetadata) normalize(t tile.Tile) (TileMetadata, error) {
+	result := make(TileMetadata, len(md))
+	for name, value := range md {
+		field, ok := t.Field(name)
+		if !ok {
+			return nil, fmt.Errorf("tile %v has no metadata field %q", t.Name, name)
+		}
+		v, err := field.Convert(value)
+		if err != nil {
+			return nil, err
+		}
+		if v != field.DefaultValue() {
+			result[name] = v
+		}
+	}
+	return result, nil
+}
+
+// TileChanged is emitted by map when tile or its metadata was changed
+type TileChanged struct {
+	Position coord.Position
+
+	OldTile uint8
+	NewTile uint8
+
+	Metadata TileMetadata
+}
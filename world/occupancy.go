@@ -0,0 +1,103 @@
+package world
+
+import (
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+type footprint struct {
+	origin coord.Position
+	size   coord.Size
+}
+
+// Occupancy tracks tiles covered by footprints of entities with MapItemBlock
+type Occupancy struct {
+	tiles      map[coord.Position][]uint64
+	footprints map[uint64]footprint
+}
+
+// NewOccupancy creates empty occupancy grid
+func NewOccupancy() *Occupancy {
+	return &Occupancy{
+		tiles:      make(map[coord.Position][]uint64),
+		footprints: make(map[uint64]footprint),
+	}
+}
+
+// Place sets footprint of entity, only tiles which differ from previous footprint are updated
+func (o *Occupancy) Place(id uint64, origin coord.Position, size coord.Size) {
+	old, ok := o.footprints[id]
+	if ok && old.origin == origin && old.size == size {
+		return
+	}
+	o.footprints[id] = footprint{origin: origin, size: size}
+
+	if ok {
+		for _, p := range old.size.Box(old.origin) {
+			if !contains(origin, size, p) {
+				o.release(id, p)
+			}
+		}
+	}
+	for _, p := range size.Box(origin) {
+		if !ok || !contains(old.origin, old.size, p) {
+			o.tiles[p] = append(o.tiles[p], id)
+		}
+	}
+}
+
+// Remove releases all tiles occupied by entity
+func (o *Occupancy) Remove(id uint64) {
+	old, ok := o.footprints[id]
+	if !ok {
+		return
+	}
+	delete(o.footprints, id)
+	for _, p := range old.size.Box(old.origin) {
+		o.release(id, p)
+	}
+}
+
+func (o *Occupancy) release(id uint64, p coord.Position) {
+	ids := o.tiles[p]
+	for i, v := range ids {
+		if v == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(o.tiles, p)
+	} else {
+		o.tiles[p] = ids
+	}
+}
+
+// OccupiedBy returns ids of entities covering tile
+func (o *Occupancy) OccupiedBy(p coord.Position) []uint64 {
+	return append([]uint64(nil), o.tiles[p]...)
+}
+
+// IsFree returns true if tile is not covered by any entity other than ignored one
+func (o *Occupancy) IsFree(p coord.Position, ignore uint64) bool {
+	for _, id := range o.tiles[p] {
+		if id != ignore {
+			return false
+		}
+	}
+	return true
+}
+
+// IsAreaFree returns true if no tile in box is covered by entities other than ignored one
+func (o *Occupancy) IsAreaFree(origin coord.Position, size coord.Size, ignore uint64) bool {
+	for _, p := range size.Box(origin) {
+		if !o.IsFree(p, ignore) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(origin coord.Position, size coord.Size, p coord.Position) bool {
+	d := p.Sub(origin)
+	return d.X >= 0 && d.Y >= 0 && d.Z >= 0 && d.X < size.X && d.Y < size.Y && d.Z < size.Z
+}
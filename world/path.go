@@ -0,0 +1,106 @@
+package world
+
+import (
+	"math"
+
+	"github.com/Tomislaw/far-worlds/pathfinding/astar"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+// pathSearchMargin limits how far outside of box between start and goal path can go,
+// so search on unbounded maps ends when goal is unreachable
+const pathSearchMargin = 32
+
+// pathSearch contains parameters shared by all tiles visited during single search
+type pathSearch struct {
+	m        *Map
+	size     coord.Size
+	ignore   uint64
+	min, max coord.Position
+}
+
+// pathTile implements astar.Pather for tile on map
+type pathTile struct {
+	search *pathSearch
+	p      coord.Position
+}
+
+func (t pathTile) PathNeighbors() []astar.Pather {
+	var result []astar.Pather
+	for _, offset := range coord.Neighbors8 {
+		n := t.p.Add(offset)
+		if !canStep(t.p, n, t.search.canEnter) {
+			continue
+		}
+		result = append(result, pathTile{t.search, n})
+	}
+	return result
+}
+
+func (t pathTile) PathNeighborCost(to astar.Pather) float64 {
+	n := to.(pathTile).p
+	// wading through shallow liquid is slow
+	cost := 1 + float64(t.search.m.LiquidLevel(n))
+	if n.X != t.p.X && n.Y != t.p.Y {
+		return cost * math.Sqrt2
+	}
+	return cost
+}
+
+func (t pathTile) PathEstimatedCost(to astar.Pather) float64 {
+	d := to.(pathTile).p.Sub(t.p)
+	dx, dy := math.Abs(float64(d.X)), math.Abs(float64(d.Y))
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+func (s *pathSearch) canEnter(p coord.Position) bool {
+	if p.X < s.min.X || p.Y < s.min.Y || p.X > s.max.X || p.Y > s.max.Y {
+		return false
+	}
+	return s.m.passable(p, s.size, s.ignore)
+}
+
+// FindPath finds path on single z level for footprint of given size, tiles occupied by
+// ignored entity are treated as free. Returned path starts with from and ends with to.
+func (m *Map) FindPath(from coord.Position, to coord.Position, size coord.Size, ignore uint64) ([]coord.Position, bool) {
+	if from.Z != to.Z {
+		return nil, false
+	}
+	margin := coord.Position{X: pathSearchMargin, Y: pathSearchMargin}
+	search := &pathSearch{
+		m:      m,
+		size:   size,
+		ignore: ignore,
+		min:    coord.Position{X: minInt(from.X, to.X), Y: minInt(from.Y, to.Y)}.Sub(margin),
+		max:    coord.Position{X: maxInt(from.X, to.X), Y: maxInt(from.Y, to.Y)}.Add(margin),
+	}
+	if !search.canEnter(to) {
+		return nil, false
+	}
+
+	path, _, found := astar.Path(pathTile{search, from}, pathTile{search, to})
+	if !found {
+		return nil, false
+	}
+
+	// astar returns path from goal to start
+	result := make([]coord.Position, len(path))
+	for i, p := range path {
+		result[len(path)-1-i] = p.(pathTile).p
+	}
+	return result, true
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,176 @@
+package world
+
+import (
+	"math"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+	"github.com/Tomislaw/far-worlds/world/tile"
+)
+
+// Face is side of tile through which ray entered it
+type Face uint8
+
+const (
+	FaceNone Face = iota
+	FaceNegX
+	FacePosX
+	FaceNegY
+	FacePosY
+	FaceNegZ
+	FacePosZ
+)
+
+// RaycastHit describes first blocking tile found by raycast
+type RaycastHit struct {
+	Position coord.Position
+	Face     Face
+	Tile     tile.Tile
+	// Distance from center of start tile to point where ray entered hit tile
+	Distance float64
+	// Unknown is set when ray hit edge of map or chunk which isn't loaded, Tile is empty then
+	Unknown bool
+}
+
+// Raycast traverses tiles on line between centers of from and to tiles, using
+// Amanatides-Woo voxel traversal. Returns first tile which is not transparent,
+// start tile is never reported. Tiles outside of map or loaded chunks block ray like in
+// FieldOfView, they're reported as Unknown hit.
+func (m *Map) Raycast(from coord.Position, to coord.Position) (RaycastHit, bool) {
+	d := to.Sub(from)
+	dir := [3]float64{float64(d.X), float64(d.Y), float64(d.Z)}
+	length := math.Sqrt(dir[0]*dir[0] + dir[1]*dir[1] + dir[2]*dir[2])
+	if length == 0 {
+		return RaycastHit{}, false
+	}
+
+	current := [3]int{from.X, from.Y, from.Z}
+	target := [3]int{to.X, to.Y, to.Z}
+	negFaces := [3]Face{FaceNegX, FaceNegY, FaceNegZ}
+	posFaces := [3]Face{FacePosX, FacePosY, FacePosZ}
+
+	// t is parameter of ray in range 0..1, ray starts in center of tile
+	var step [3]int
+	var tMax, tDelta [3]float64
+	for i := range dir {
+		switch {
+		case dir[i] > 0:
+			step[i] = 1
+			tDelta[i] = 1 / dir[i]
+			tMax[i] = 0.5 / dir[i]
+		case dir[i] < 0:
+			step[i] = -1
+			tDelta[i] = -1 / dir[i]
+			tMax[i] = -0.5 / dir[i]
+		default:
+			tMax[i] = math.Inf(1)
+			tDelta[i] = math.Inf(1)
+		}
+	}
+
+	for current != target {
+		axis := 0
+		if tMax[1] < tMax[axis] {
+			axis = 1
+		}
+		if tMax[2] < tMax[axis] {
+			axis = 2
+		}
+		t := tMax[axis]
+		if t > 1 {
+			break
+		}
+		current[axis] += step[axis]
+		tMax[axis] += tDelta[axis]
+
+		p := coord.Position{X: current[0], Y: current[1], Z: current[2]}
+		t2, loaded := m.loadedTile(p)
+		if loaded && t2.IsTransparent() {
+			continue
+		}
+
+		// entering tile while moving in positive direction happens through its negative face
+		face := negFaces[axis]
+		if step[axis] < 0 {
+			face = posFaces[axis]
+		}
+		return RaycastHit{Position: p, Face: face, Tile: t2, Distance: t * length, Unknown: !loaded}, true
+	}
+	return RaycastHit{}, false
+}
+
+// LineOfSight returns true if nothing blocks sight between from and to, target tile itself
+// may be opaque. Like in FieldOfView, tiles outside of map are never seen and tiles of chunks
+// which aren't loaded block sight.
+func (m *Map) LineOfSight(from coord.Position, to coord.Position) bool {
+	if !m.dimensions.Contains(to) {
+		return false
+	}
+	hit, ok := m.Raycast(from, to)
+	return !ok || hit.Position == to
+}
+
+// shadowcast octants transforming (row, column) to x and y offsets
+var octants = [8][4]int{
+	{1, 0, 0, 1}, {0, 1, 1, 0}, {0, -1, 1, 0}, {-1, 0, 0, 1},
+	{-1, 0, 0, -1}, {0, -1, -1, 0}, {0, 1, -1, 0}, {1, 0, 0, -1},
+}
+
+// FieldOfView returns tiles visible from origin on its z level within radius, using
+// recursive shadowcasting. Opaque tiles which are seen are included in result, tiles of
+// chunks which aren't loaded are opaque.
+func (m *Map) FieldOfView(origin coord.Position, radius int) map[coord.Position]bool {
+	visible := map[coord.Position]bool{origin: true}
+	for _, o := range octants {
+		m.castLight(visible, origin, radius, 1, 1.0, 0.0, o)
+	}
+	return visible
+}
+
+func (m *Map) castLight(visible map[coord.Position]bool, origin coord.Position, radius int, row int, start float64, end float64, o [4]int) {
+	if start < end {
+		return
+	}
+	radiusSquared := radius * radius
+	for j := row; j <= radius; j++ {
+		blocked := false
+		newStart := start
+		for dx := -j; dx <= 0; dx++ {
+			dy := -j
+			leftSlope := (float64(dx) - 0.5) / (float64(dy) + 0.5)
+			rightSlope := (float64(dx) + 0.5) / (float64(dy) - 0.5)
+			if start < rightSlope {
+				continue
+			}
+			if end > leftSlope {
+				break
+			}
+
+			p := coord.Position{
+				X: origin.X + dx*o[0] + dy*o[1],
+				Y: origin.Y + dx*o[2] + dy*o[3],
+				Z: origin.Z,
+			}
+			if dx*dx+dy*dy <= radiusSquared && m.dimensions.Contains(p) {
+				visible[p] = true
+			}
+
+			t, loaded := m.loadedTile(p)
+			opaque := !loaded || !t.IsTransparent()
+			if blocked {
+				if opaque {
+					newStart = rightSlope
+					continue
+				}
+				blocked = false
+				start = newStart
+			} else if opaque && j < radius {
+				blocked = true
+				m.castLight(visible, origin, radius, j+1, start, leftSlope, o)
+				newStart = rightSlope
+			}
+		}
+		if blocked {
+			break
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+func TestUnloadedChunksBlockSight(t *testing.T) {
+	w := NewWorld(Config{ChunkWidth: 16, ChunkHeight: 4})
+	m, _ := w.CreateMap(1, 2, 2)
+	from, to := coord.Position{X: 1, Y: 1, Z: 1}, coord.Position{X: 20, Y: 1, Z: 1}
+	// only chunk of origin is loaded
+	m.GetChunk(m.Dimensions().ToChunk(from))
+
+	hit, ok := m.Raycast(from, to)
+	if !ok || !hit.Unknown || hit.Position != (coord.Position{X: 16, Y: 1, Z: 1}) {
+		t.Fatalf("expected ray to be blocked at edge of loaded chunk, got %+v", hit)
+	}
+	if m.LineOfSight(from, to) {
+		t.Fatal("expected no line of sight through unloaded chunk")
+	}
+	if m.FieldOfView(from, 24)[to] {
+		t.Fatal("expected field of view not to see through unloaded chunk")
+	}
+
+	m.GetChunk(m.Dimensions().ToChunk(to))
+	if !m.LineOfSight(from, to) || !m.FieldOfView(from, 24)[to] {
+		t.Fatal("expected line of sight and field of view to agree when chunks are loaded")
+	}
+	if m.LineOfSight(from, coord.Position{X: -1, Y: 1, Z: 1}) {
+		t.Fatal("expected tiles outside of map not to be seen")
+	}
+}
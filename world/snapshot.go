@@ -0,0 +1,176 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Tomislaw/far-worlds/ecs"
+)
+
+// MultiError contains errors of operations which continued after first failure
+type MultiError []error
+
+func (e MultiError) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%v errors: %v", len(e), strings.Join(messages, "; "))
+}
+
+// errorOrNil returns nil if there are no errors, so empty MultiError isn't returned as error
+func (e MultiError) errorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// entitySnapshot is saved entity, components are keyed by their registered names
+type entitySnapshot struct {
+	Components map[string]json.RawMessage `json:"components"`
+	// Tags are registered names of tag components, which have no data
+	Tags []string `json:"tags,omitempty"`
+	// Children are indexes of child entities in saved list
+	Children  []int              `json:"children,omitempty"`
+	Relations []relationSnapshot `json:"relations,omitempty"`
+}
+
+// relationSnapshot is saved relation, target is index of entity in saved list
+type relationSnapshot struct {
+	Kind   ecs.RelationKind `json:"kind"`
+	Target int              `json:"target"`
+	Data   string           `json:"data,omitempty"`
+}
+
+func (m *Map) entitiesKey() string {
+	return m.name + "/entities.json"
+}
+
+// Save saves modified chunks and all entities of map to storage. Can't be called
+// concurrently with Update, main loop must be stopped or function run between ticks.
+func (m *Map) Save() error {
+	var errs MultiError
+	if err := m.globalChunkManager.SaveAll(); err != nil {
+		errs = append(errs, fmt.Errorf("map %v: %v", m.id, err))
+	}
+	if err := m.saveEntities(); err != nil {
+		errs = append(errs, fmt.Errorf("map %v: %v", m.id, err))
+	}
+	return errs.errorOrNil()
+}
+
+func (m *Map) saveEntities() error {
+	if m.storage == nil {
+		return nil
+	}
+	entities, values := m.manager.Snapshot()
+	indexes := make(map[*ecs.Entity]int, len(entities))
+	for i, e := range entities {
+		indexes[e] = i
+	}
+	snapshots := make([]entitySnapshot, len(values))
+	for i, components := range values {
+		for _, child := range entities[i].Children() {
+			if index, ok := indexes[child]; ok {
+				snapshots[i].Children = append(snapshots[i].Children, index)
+			}
+		}
+		for _, r := range m.manager.AllRelations(entities[i]) {
+			if index, ok := indexes[r.Target]; ok {
+				snapshots[i].Relations = append(snapshots[i].Relations, relationSnapshot{Kind: r.Kind, Target: index, Data: r.Data})
+			}
+		}
+		snapshots[i].Components = make(map[string]json.RawMessage, len(components))
+		for _, c := range components {
+			name := m.manager.ComponentName(reflect.TypeOf(c))
+			if m.manager.IsTag(reflect.TypeOf(c)) {
+				snapshots[i].Tags = append(snapshots[i].Tags, name)
+				continue
+			}
+			data, err := json.Marshal(c)
+			if err != nil {
+				return fmt.Errorf("failed to save component %T: %v", c, err)
+			}
+			snapshots[i].Components[name] = data
+		}
+		sort.Strings(snapshots[i].Tags)
+	}
+	data, err := json.Marshal(snapshots)
+	if err != nil {
+		return err
+	}
+	if err := m.storage.Save(m.entitiesKey(), data); err != nil {
+		return fmt.Errorf("failed to save entities: %v", err)
+	}
+	return nil
+}
+
+// loadEntities restores entities saved by Save, restored entities get new ids
+func (m *Map) loadEntities() error {
+	if m.storage == nil {
+		return nil
+	}
+	data, err := m.storage.Load(m.entitiesKey())
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load entities: %v", err)
+	}
+	var snapshots []entitySnapshot
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return fmt.Errorf("failed to load entities: %v", err)
+	}
+
+	entities := make([]*ecs.Entity, len(snapshots))
+	for i, snapshot := range snapshots {
+		components := make([]interface{}, 0, len(snapshot.Components))
+		for name, raw := range snapshot.Components {
+			t, ok := m.manager.ComponentByName(name)
+			if !ok {
+				fmt.Printf("Dropping unknown component %v on map %v\n", name, m.id)
+				continue
+			}
+			value := reflect.New(t)
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return fmt.Errorf("failed to load component %v: %v", name, err)
+			}
+			components = append(components, value.Elem().Interface())
+		}
+		for _, name := range snapshot.Tags {
+			t, ok := m.manager.ComponentByName(name)
+			if !ok {
+				fmt.Printf("Dropping unknown tag %v on map %v\n", name, m.id)
+				continue
+			}
+			components = append(components, reflect.Zero(t).Interface())
+		}
+		entities[i] = ecs.NewEntity(m.manager)
+		if err := m.manager.InsertEntity(entities[i], components); err != nil {
+			return fmt.Errorf("failed to load entities: %v", err)
+		}
+	}
+	for i, snapshot := range snapshots {
+		for _, child := range snapshot.Children {
+			if child < 0 || child >= len(entities) {
+				return fmt.Errorf("failed to load entities: invalid child %v of entity %v", child, i)
+			}
+			if err := entities[i].AppendChild(entities[child]); err != nil {
+				return fmt.Errorf("failed to load entities: %v", err)
+			}
+		}
+		for _, r := range snapshot.Relations {
+			if r.Target < 0 || r.Target >= len(entities) {
+				return fmt.Errorf("failed to load entities: invalid %v relation target %v of entity %v", r.Kind, r.Target, i)
+			}
+			if err := m.manager.AddRelation(r.Kind, entities[i], entities[r.Target], r.Data); err != nil {
+				return fmt.Errorf("failed to load entities: %v", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/component"
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+func TestSaveAndLoadEntities(t *testing.T) {
+	storage := NewFileStorage(t.TempDir())
+	dimensions := coord.Dimensions{ChunkWidth: 4, ChunkHeight: 2, MapWidth: 1, MapHeight: 1}
+	m, err := LoadMap(MapConfig{ID: 1, Dimensions: dimensions, Storage: storage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := addItem(m, "owner", coord.Position{X: 1, Y: 1, Z: 1})
+	owner.AddComponent(component.Player{})
+	sword, shield := addItem(m, "sword", coord.Position{}), addItem(m, "shield", coord.Position{})
+	m.Update(0)
+	owner.AppendChild(sword)
+	owner.InsertChild(shield, 0)
+	if err := m.Manager().AddRelation(component.EquippedIn, sword, owner, "hand"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadMap(MapConfig{ID: 1, Dimensions: dimensions, Storage: storage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := reloaded.Manager()
+	find := func(guid string) *ecs.Entity {
+		e, ok := component.FindByGUID(manager, guid)
+		if !ok {
+			t.Fatalf("expected entity %v to be loaded", guid)
+		}
+		return e
+	}
+	owner, sword, shield = find("owner"), find("sword"), find("shield")
+
+	children := owner.Children()
+	if len(children) != 2 || children[0] != shield || children[1] != sword {
+		t.Fatalf("expected children to keep their order, got %v", children)
+	}
+	if relation, ok := manager.Relation(component.EquippedIn, sword, owner); !ok || relation.Data != "hand" {
+		t.Fatalf("expected relation with its data to be loaded, got %+v", relation)
+	}
+	if _, ok := ecs.Get[component.Player](owner); !ok {
+		t.Fatal("expected tag to be loaded")
+	}
+	if item, _ := ecs.Get[component.MapItem](owner); item.Position != (coord.Position{X: 1, Y: 1, Z: 1}) {
+		t.Fatalf("expected component values to be loaded, got %+v", item)
+	}
+}
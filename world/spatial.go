@@ -0,0 +1,242 @@
+package world
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+// SpatialIndex keeps entities with MapItem bucketed by chunk and tile
+type SpatialIndex struct {
+	dimensions coord.Dimensions
+
+	positions map[uint64]coord.Position
+	chunks    map[coord.ChunkPosition]map[uint64]*ecs.Entity
+	tiles     map[coord.Position][]*ecs.Entity
+}
+
+// NewSpatialIndex creates empty index for map of given dimensions
+func NewSpatialIndex(dimensions coord.Dimensions) *SpatialIndex {
+	return &SpatialIndex{
+		dimensions: dimensions,
+		positions:  make(map[uint64]coord.Position),
+		chunks:     make(map[coord.ChunkPosition]map[uint64]*ecs.Entity),
+		tiles:      make(map[coord.Position][]*ecs.Entity),
+	}
+}
+
+// Update moves entity to position, adding it to index if needed
+func (si *SpatialIndex) Update(entity *ecs.Entity, p coord.Position) {
+	if old, ok := si.positions[entity.ID()]; ok {
+		if old == p {
+			return
+		}
+		si.Remove(entity)
+	}
+
+	si.positions[entity.ID()] = p
+	si.tiles[p] = append(si.tiles[p], entity)
+
+	c := si.dimensions.ToChunk(p)
+	bucket, ok := si.chunks[c]
+	if !ok {
+		bucket = make(map[uint64]*ecs.Entity)
+		si.chunks[c] = bucket
+	}
+	bucket[entity.ID()] = entity
+}
+
+// Remove removes entity from index
+func (si *SpatialIndex) Remove(entity *ecs.Entity) {
+	p, ok := si.positions[entity.ID()]
+	if !ok {
+		return
+	}
+	delete(si.positions, entity.ID())
+
+	onTile := si.tiles[p]
+	for i, e := range onTile {
+		if e.ID() == entity.ID() {
+			onTile = append(onTile[:i], onTile[i+1:]...)
+			break
+		}
+	}
+	if len(onTile) == 0 {
+		delete(si.tiles, p)
+	} else {
+		si.tiles[p] = onTile
+	}
+
+	c := si.dimensions.ToChunk(p)
+	delete(si.chunks[c], entity.ID())
+	if len(si.chunks[c]) == 0 {
+		delete(si.chunks, c)
+	}
+}
+
+// Position returns indexed position of entity
+func (si *SpatialIndex) Position(entity *ecs.Entity) (coord.Position, bool) {
+	p, ok := si.positions[entity.ID()]
+	return p, ok
+}
+
+// Len returns count of indexed entities
+func (si *SpatialIndex) Len() int {
+	return len(si.positions)
+}
+
+// OnTile returns entities standing on tile
+func (si *SpatialIndex) OnTile(p coord.Position) []*ecs.Entity {
+	return append([]*ecs.Entity(nil), si.tiles[p]...)
+}
+
+// InChunk returns entities inside chunk
+func (si *SpatialIndex) InChunk(c coord.ChunkPosition) []*ecs.Entity {
+	result := make([]*ecs.Entity, 0, len(si.chunks[c]))
+	for _, e := range si.chunks[c] {
+		result = append(result, e)
+	}
+	return result
+}
+
+// InRect returns entities inside box between min and max, inclusive
+func (si *SpatialIndex) InRect(min coord.Position, max coord.Position) []*ecs.Entity {
+	var result []*ecs.Entity
+	si.eachChunk(min, max, func(bucket map[uint64]*ecs.Entity) {
+		for id, e := range bucket {
+			p := si.positions[id]
+			if p.X >= min.X && p.X <= max.X && p.Y >= min.Y && p.Y <= max.Y && p.Z >= min.Z && p.Z <= max.Z {
+				result = append(result, e)
+			}
+		}
+	})
+	return result
+}
+
+// InRadius returns entities with euclidean distance to center not bigger than radius
+func (si *SpatialIndex) InRadius(center coord.Position, radius float64) []*ecs.Entity {
+	r := int(math.Ceil(radius))
+	offset := coord.Position{X: r, Y: r, Z: r}
+
+	var result []*ecs.Entity
+	si.eachChunk(center.Sub(offset), center.Add(offset), func(bucket map[uint64]*ecs.Entity) {
+		for id, e := range bucket {
+			if distance(center, si.positions[id]) <= radius {
+				result = append(result, e)
+			}
+		}
+	})
+	return result
+}
+
+// Nearest returns up to k entities closest to center, not further than maxRadius,
+// sorted by distance. Chunks are searched in rings around center and search stops
+// when remaining chunks can't contain closer entities.
+func (si *SpatialIndex) Nearest(center coord.Position, k int, maxRadius float64) []*ecs.Entity {
+	if k <= 0 {
+		return nil
+	}
+
+	type candidate struct {
+		entity   *ecs.Entity
+		distance float64
+	}
+	var candidates []candidate
+
+	centerChunk := si.dimensions.ToChunk(center)
+	// ring count is clamped before conversion, so infinite radius doesn't overflow
+	maxRing := si.lastRing(centerChunk)
+	if rings := math.Ceil(maxRadius/float64(si.dimensions.ChunkWidth)) + 1; rings < float64(maxRing) {
+		maxRing = int(rings)
+	}
+	for ring := 0; ring <= maxRing; ring++ {
+		for x := centerChunk.X - ring; x <= centerChunk.X+ring; x++ {
+			for y := centerChunk.Y - ring; y <= centerChunk.Y+ring; y++ {
+				c := coord.ChunkPosition{X: x, Y: y}
+				if c.Chebyshev(centerChunk) != ring {
+					continue
+				}
+				for id, e := range si.chunks[c] {
+					if d := distance(center, si.positions[id]); d <= maxRadius {
+						candidates = append(candidates, candidate{e, d})
+					}
+				}
+			}
+		}
+
+		// entities in next rings are at least ring*ChunkWidth tiles away
+		sort.Slice(candidates, func(i, j int) bool {
+			if candidates[i].distance == candidates[j].distance {
+				return candidates[i].entity.ID() < candidates[j].entity.ID()
+			}
+			return candidates[i].distance < candidates[j].distance
+		})
+		if len(candidates) >= k && candidates[k-1].distance <= float64(ring*si.dimensions.ChunkWidth) {
+			break
+		}
+	}
+
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	result := make([]*ecs.Entity, len(candidates))
+	for i, c := range candidates {
+		result[i] = c.entity
+	}
+	return result
+}
+
+// lastRing returns ring around center containing furthest chunk of map, or furthest chunk
+// with entities if map is unbounded
+func (si *SpatialIndex) lastRing(center coord.ChunkPosition) int {
+	ring := 0
+	if si.dimensions.Bounded() {
+		for _, corner := range []coord.ChunkPosition{
+			{X: 0, Y: 0},
+			{X: si.dimensions.MapWidth - 1, Y: 0},
+			{X: 0, Y: si.dimensions.MapHeight - 1},
+			{X: si.dimensions.MapWidth - 1, Y: si.dimensions.MapHeight - 1},
+		} {
+			if d := corner.Chebyshev(center); d > ring {
+				ring = d
+			}
+		}
+		return ring
+	}
+	for c := range si.chunks {
+		if d := c.Chebyshev(center); d > ring {
+			ring = d
+		}
+	}
+	return ring
+}
+
+func (si *SpatialIndex) eachChunk(min coord.Position, max coord.Position, f func(map[uint64]*ecs.Entity)) {
+	minChunk := si.dimensions.ToChunk(min)
+	maxChunk := si.dimensions.ToChunk(max)
+
+	// iterating over sparse buckets is cheaper than over large empty area
+	if (maxChunk.X-minChunk.X+1)*(maxChunk.Y-minChunk.Y+1) > len(si.chunks) {
+		for c, bucket := range si.chunks {
+			if c.X >= minChunk.X && c.X <= maxChunk.X && c.Y >= minChunk.Y && c.Y <= maxChunk.Y {
+				f(bucket)
+			}
+		}
+		return
+	}
+
+	for x := minChunk.X; x <= maxChunk.X; x++ {
+		for y := minChunk.Y; y <= maxChunk.Y; y++ {
+			if bucket, ok := si.chunks[coord.ChunkPosition{X: x, Y: y}]; ok {
+				f(bucket)
+			}
+		}
+	}
+}
+
+func distance(a coord.Position, b coord.Position) float64 {
+	d := a.Sub(b)
+	return math.Sqrt(float64(d.X*d.X + d.Y*d.Y + d.Z*d.Z))
+}
@@ -0,0 +1,28 @@
+package world
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+func TestNearestWithInfiniteRadius(t *testing.T) {
+	manager := ecs.NewManager()
+	near, far := ecs.NewEntity(manager), ecs.NewEntity(manager)
+	for _, dimensions := range []coord.Dimensions{
+		{ChunkWidth: 16, ChunkHeight: 4, MapWidth: 4, MapHeight: 4},
+		{ChunkWidth: 16, ChunkHeight: 4},
+	} {
+		index := NewSpatialIndex(dimensions)
+		index.Update(near, coord.Position{X: 2, Y: 2})
+		index.Update(far, coord.Position{X: 60, Y: 50})
+
+		result := index.Nearest(coord.Position{X: 0, Y: 0}, 2, math.Inf(1))
+		if !reflect.DeepEqual(result, []*ecs.Entity{near, far}) {
+			t.Fatalf("expected both entities sorted by distance on %+v, got %v", dimensions, result)
+		}
+	}
+}
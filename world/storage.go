@@ -0,0 +1,52 @@
+package world
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ErrNotFound is returned by storage when there is no data saved under key
+var ErrNotFound = errors.New("not found")
+
+// Storage persists world data like chunks, keys are slash separated paths
+type Storage interface {
+	Load(key string) ([]byte, error)
+	Save(key string, data []byte) error
+}
+
+// FileStorage saves each key as separate file inside directory
+type FileStorage struct {
+	dir string
+}
+
+// NewFileStorage creates storage saving files in dir
+func NewFileStorage(dir string) *FileStorage {
+	return &FileStorage{dir: dir}
+}
+
+func (s *FileStorage) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+// Load reads file saved under key, returns ErrNotFound if there is no such file
+func (s *FileStorage) Load(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Save writes data to temporary file and renames it, so partially written files are never loaded
+func (s *FileStorage) Save(key string, data []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
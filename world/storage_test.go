@@ -0,0 +1,63 @@
+package world
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+func TestFileStorageRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	storage := NewFileStorage(dir)
+	if _, err := storage.Load("maps/1/chunk"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	for _, data := range []string{"first", "second"} {
+		if err := storage.Save("maps/1/chunk", []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := storage.Load("maps/1/chunk")
+		if err != nil || string(loaded) != data {
+			t.Fatalf("expected %q, got %q and %v", data, loaded, err)
+		}
+	}
+
+	files, err := os.ReadDir(filepath.Join(dir, "maps", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "chunk" {
+		t.Fatalf("expected only renamed file to be left, got %v", files)
+	}
+}
+
+func TestStopSavesMaps(t *testing.T) {
+	storage := NewFileStorage(t.TempDir())
+	w := NewWorld(Config{Storage: storage})
+	m, _ := w.CreateMap(1, 1, 1)
+	p := coord.Position{X: 1, Y: 1, Z: 1}
+	w.Start(context.Background())
+	done := make(chan struct{})
+	m.RunBetweenTicks(func() {
+		m.SetTile(p, 1)
+		close(done)
+	})
+	<-done
+	if err := w.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, _ := NewWorld(Config{Storage: storage}).CreateMap(1, 1, 1)
+	reloaded.GetChunk(m.Dimensions().ToChunk(p))
+	if reloaded.GetTile(p).Id != 1 {
+		t.Fatal("expected tile changed before stop to be saved")
+	}
+	if _, err := storage.Load("clock.json"); err != nil {
+		t.Fatalf("expected clock to be saved: %v", err)
+	}
+}
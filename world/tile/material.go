@@ -0,0 +1,49 @@
+package tile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+var Materials = MaterialAtlas{}.Load()
+
+type Material struct {
+	Id          uint8  `json:"id"`
+	Name        string `json:"name"`
+	Transparent bool   `json:"transparent"`
+}
+
+type MaterialAtlas struct {
+	Materials []Material `json:"materials"`
+}
+
+func (atlas MaterialAtlas) Load() MaterialAtlas {
+	path := findFile("materials.json")
+	fmt.Println("Loading material atlas: " + path)
+	byteValue, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Println("Failed to read material list: ", err.Error())
+		panic(err.Error())
+	}
+
+	err = json.Unmarshal(byteValue, &atlas)
+	if err != nil {
+		fmt.Println("Failed to parse material list: ", err.Error())
+		panic(err.Error())
+	}
+	return atlas
+}
+
+// Get returns material with id or empty material if there is no such material
+func (atlas *MaterialAtlas) Get(id uint8) Material {
+	if int(id) < len(atlas.Materials) && atlas.Materials[id].Id == id {
+		return atlas.Materials[id]
+	}
+	for _, m := range atlas.Materials {
+		if m.Id == id {
+			return m
+		}
+	}
+	return Material{Id: id}
+}
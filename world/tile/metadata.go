@@ -0,0 +1,106 @@
+package tile
+
+import "fmt"
+
+// MetadataType is type of value stored in tile metadata field
+type MetadataType string
+
+const (
+	MetadataInt    MetadataType = "int"
+	MetadataFloat  MetadataType = "float"
+	MetadataBool   MetadataType = "bool"
+	MetadataString MetadataType = "string"
+)
+
+// MetadataField declares single metadata field of tile, like durability or liquid level
+type MetadataField struct {
+	Name    string       `json:"name"`
+	Type    MetadataType `json:"type"`
+	Default interface{}  `json:"default"`
+}
+
+// Field returns metadata field declared by tile
+func (t Tile) Field(name string) (MetadataField, bool) {
+	for _, field := range t.Metadata {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return MetadataField{}, false
+}
+
+// Convert converts value to type of this field. Numbers decoded from json are
+// float64, so they are converted to int when needed.
+func (f MetadataField) Convert(value interface{}) (interface{}, error) {
+	switch f.Type {
+	case MetadataInt:
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case int64:
+			return int(v), nil
+		case uint8:
+			return int(v), nil
+		case float64:
+			if v != float64(int(v)) {
+				break
+			}
+			return int(v), nil
+		}
+	case MetadataFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int:
+			return float64(v), nil
+		}
+	case MetadataBool:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case MetadataString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown metadata type %q of field %q", f.Type, f.Name)
+	}
+	return nil, fmt.Errorf("invalid value %v for %v field %q", value, f.Type, f.Name)
+}
+
+// DefaultValue returns default value of field converted to field type
+func (f MetadataField) DefaultValue() interface{} {
+	if f.Default != nil {
+		if v, err := f.Convert(f.Default); err == nil {
+			return v
+		}
+	}
+	switch f.Type {
+	case MetadataInt:
+		return 0
+	case MetadataFloat:
+		return 0.0
+	case MetadataBool:
+		return false
+	case MetadataString:
+		return ""
+	}
+	return nil
+}
+
+func (f MetadataField) validate() error {
+	if f.Name == "" {
+		return fmt.Errorf("metadata field without name")
+	}
+	if _, err := f.Convert(f.DefaultValue()); err != nil {
+		return err
+	}
+	if f.Default != nil {
+		if _, err := f.Convert(f.Default); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,8 +1,23 @@
 package tile
 
 type Tile struct {
-	Id         uint16 `json:"id"`
-	MaterialID uint8  `json:"material"`
-	Name       string `json:"name"`
-	Block      bool   `json:"block"`
+	Id          uint16          `json:"id"`
+	MaterialID  uint8           `json:"material"`
+	Name        string          `json:"name"`
+	Block       bool            `json:"block"`
+	Transparent bool            `json:"transparent"`
+	Light       uint8           `json:"light"`
+	Liquid      bool            `json:"liquid"`
+	Metadata    []MetadataField `json:"metadata,omitempty"`
+}
+
+// Material returns material of tile
+func (t Tile) Material() Material {
+	return Materials.Get(t.MaterialID)
+}
+
+// IsTransparent returns true if light and sight pass through tile, either tile
+// or its material can be transparent
+func (t Tile) IsTransparent() bool {
+	return t.Transparent || t.Material().Transparent
 }
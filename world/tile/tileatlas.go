@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -15,7 +16,7 @@ type TileAtlas struct {
 }
 
 func (atlas TileAtlas) Load() TileAtlas {
-	path := "tiles.json"
+	path := findFile("tiles.json")
 	fmt.Println("Loading tile atlas: " + path)
 	jsonFile, err := os.Open(path)
 
@@ -35,9 +36,23 @@ func (atlas TileAtlas) Load() TileAtlas {
 		fmt.Println("Failed to parse tile list: ", err.Error())
 		panic(err.Error())
 	}
+
+	for _, t := range atlas.Tiles {
+		for _, field := range t.Metadata {
+			if err := field.validate(); err != nil {
+				fmt.Printf("Invalid metadata of tile %v: %v\n", t.Name, err.Error())
+				panic(err.Error())
+			}
+		}
+	}
 	return atlas
 }
 
+// Contains returns true if tile with id is declared in atlas
+func (atlas *TileAtlas) Contains(id uint8) bool {
+	return int(id) < len(atlas.Tiles)
+}
+
 func (atlas *TileAtlas) String() (s string) {
 	s += ""
 	for key, val := range atlas.Tiles {
@@ -46,3 +61,23 @@ func (atlas *TileAtlas) String() (s string) {
 	s = strings.TrimSuffix(s, "\n")
 	return
 }
+
+// findFile returns path of file in working directory or closest parent directory containing
+// it, so atlases are found also when tests are run from package directories
+func findFile(name string) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return name
+	}
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return name
+		}
+		dir = parent
+	}
+}
@@ -1,13 +1,454 @@
 package world
 
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Tomislaw/far-worlds/component"
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+// Config contains settings shared by all maps of world
+type Config struct {
+	// ChunkWidth is count of tiles in chunk along x and y axis, defaults to DefaultChunkWidth
+	ChunkWidth int
+	// ChunkHeight is count of z levels, defaults to DefaultChunkHeight
+	ChunkHeight int
+	// Storage used to save chunks of all maps, may be nil
+	Storage Storage
+	// Generator used to fill chunks which were never saved, may be nil
+	Generator ChunkGenerator
+	// ChunkIdleTimeout defaults to DefaultChunkIdleTimeout
+	ChunkIdleTimeout time.Duration
+	// InboxSize is count of undelivered messages per map, defaults to DefaultInboxSize
+	InboxSize int
+	// Clock contains settings of in-game time
+	Clock ClockConfig
+	// Prefabs are templates used by Manager.Spawn on all maps, may be nil
+	Prefabs *ecs.Prefabs
+}
+
+// World contains maps identified by MapItem.MapID, each map is updated on its own goroutine
 type World struct {
-	maps []Map
+	config Config
+
+	lock    sync.RWMutex
+	maps    map[uint8]*Map
+	running bool
+	stopped chan struct{}
+
+	bus   *MessageBus
+	clock *Clock
+	// guids indexes entities of all maps, so GUIDs are unique in whole world
+	guids *ecs.Index
+}
+
+// NewWorld creates empty world, chunk size can't be changed after creation
+func NewWorld(config Config) *World {
+	if config.ChunkWidth == 0 {
+		config.ChunkWidth = DefaultChunkWidth
+	}
+	if config.ChunkHeight == 0 {
+		config.ChunkHeight = DefaultChunkHeight
+	}
+	clock, err := loadClock(config.Clock, config.Storage)
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+	return &World{
+		config: config,
+		maps:   make(map[uint8]*Map),
+		bus:    NewMessageBus(config.InboxSize),
+		clock:  clock,
+		guids:  component.NewGUIDIndex(),
+	}
+}
+
+// CreateMap adds new map with size in chunks, 0 means map is unbounded along that axis
+func (world *World) CreateMap(id uint8, width int, height int) (*Map, error) {
+	m, err := LoadMap(MapConfig{
+		ID: id,
+		Dimensions: coord.Dimensions{
+			ChunkWidth:  world.config.ChunkWidth,
+			ChunkHeight: world.config.ChunkHeight,
+			MapWidth:    width,
+			MapHeight:   height,
+		},
+		Storage:          world.config.Storage,
+		Generator:        world.config.Generator,
+		ChunkIdleTimeout: world.config.ChunkIdleTimeout,
+		Prefabs:          world.config.Prefabs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := world.AddMap(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
-func (world *World) Init() {
+// AddMap adds map to world, if world is running map main loop is started
+func (world *World) AddMap(m *Map) error {
+	world.lock.Lock()
+	defer world.lock.Unlock()
 
-	for id := range world.maps {
-		go world.maps[id].StartMainLoop()
+	if _, ok := world.maps[m.id]; ok {
+		return fmt.Errorf("map %v already exists", m.id)
+	}
+	if err := m.manager.RegisterIndex(component.Type.GUID, world.guids); err != nil {
+		return fmt.Errorf("map %v can't be added: %v", m.id, err)
 	}
+	world.maps[m.id] = m
+	world.bus.open(m.id)
+	m.bus = world.bus
+	m.clock = world.clock
+	m.manager.AddResource(world.clock)
+	if world.running {
+		m.StartMainLoop()
+	}
+	return nil
+}
+
+// RemoveMap stops map main loop, saves map and removes it from world. Map stops accepting
+// messages first, so transfers to it fail and entities stay on source maps. Entities which
+// were already transferred to map are inserted into it before it's saved.
+func (world *World) RemoveMap(id uint8) error {
+	world.lock.Lock()
+	m, ok := world.maps[id]
+	delete(world.maps, id)
+	world.lock.Unlock()
+
+	if !ok {
+		return fmt.Errorf("map %v doesn't exist", id)
+	}
+	world.bus.close(id)
+	err := m.shutdown()
+	// GUIDs of removed map are released from world index
+	if indexErr := m.manager.RegisterIndex(component.Type.GUID, component.NewGUIDIndex()); indexErr != nil {
+		fmt.Println(indexErr.Error())
+	}
+	m.bus = nil
+	m.clock = nil
+	m.manager.RemoveResource(reflect.TypeOf(world.clock))
+	return err
+}
+
+// Messages returns bus used to send messages between maps
+func (world *World) Messages() *MessageBus {
+	return world.bus
+}
 
+// Broadcast sends message to all maps, from is id of sending map or WorldSender
+func (world *World) Broadcast(from int, payload interface{}) error {
+	maps := world.Maps()
+	ids := make([]uint8, len(maps))
+	for i, m := range maps {
+		ids[i] = m.id
+	}
+	return world.bus.Broadcast(from, ids, payload)
+}
+
+// Clock returns in-game clock of world
+func (world *World) Clock() *Clock {
+	return world.clock
+}
+
+// Map returns map with id or nil if there is no such map
+func (world *World) Map(id uint8) *Map {
+	world.lock.RLock()
+	defer world.lock.RUnlock()
+	return world.maps[id]
+}
+
+// Maps returns all maps sorted by id
+func (world *World) Maps() []*Map {
+	world.lock.RLock()
+	defer world.lock.RUnlock()
+
+	result := make([]*Map, 0, len(world.maps))
+	for _, m := range world.maps {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].id < result[j].id })
+	return result
+}
+
+// FindEntity returns entity with GUID and map which manages it. GUIDs are unique in whole
+// world. Entity which is being transferred is reported on map it's leaving until it's
+// inserted into target map.
+func (world *World) FindEntity(guid string) (*Map, *ecs.Entity, bool) {
+	entity, ok := world.guids.Lookup(guid)
+	if !ok {
+		return nil, nil, false
+	}
+	m := world.mapOf(entity)
+	if m == nil {
+		return nil, nil, false
+	}
+	return m, entity, true
+}
+
+// mapOf returns map which manages entity
+func (world *World) mapOf(entity *ecs.Entity) *Map {
+	for _, m := range world.Maps() {
+		if m.manager == entity.Manager() {
+			return m
+		}
+	}
+	return nil
+}
+
+// Start starts main loops of all maps, world is stopped with Stop when ctx is cancelled
+func (world *World) Start(ctx context.Context) {
+	world.lock.Lock()
+	defer world.lock.Unlock()
+
+	if world.running {
+		return
+	}
+	world.running = true
+	world.stopped = make(chan struct{})
+	for _, m := range world.maps {
+		m.StartMainLoop()
+	}
+	world.clock.start()
+	go world.runClock(world.stopped)
+
+	go func(stopped <-chan struct{}) {
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			if err := world.Stop(context.Background()); err != nil {
+				fmt.Println(err.Error())
+			}
+		}
+	}(world.stopped)
+}
+
+// Stop stops main loops of all maps, waiting until their current ticks are finished, then
+// finishes pending transfers between maps and saves chunks and entities of each map. Returns
+// errors of all maps, or error of ctx if it's done before all maps are saved. In that case
+// maps are still being saved in background after Stop returns.
+func (world *World) Stop(ctx context.Context) error {
+	world.lock.Lock()
+	if world.running {
+		world.running = false
+		close(world.stopped)
+		world.clock.stop()
+	}
+	world.lock.Unlock()
+	maps := world.Maps()
+
+	result := make(chan MultiError, 1)
+	go func() {
+		result <- shutdown(maps)
+	}()
+
+	var errs MultiError
+	if err := world.clock.save(world.config.Storage); err != nil {
+		errs = append(errs, err)
+	}
+	select {
+	case mapErrs := <-result:
+		errs = append(errs, mapErrs...)
+	case <-ctx.Done():
+		return append(errs, fmt.Errorf("world not saved: %v", ctx.Err()))
+	}
+	return errs.errorOrNil()
+}
+
+// shutdown stops main loops of maps, drains their pending work together, so entities in
+// transit between them are inserted into target maps, and saves them. Maps are locked in
+// order of ids, so concurrent shutdowns are saving one by one.
+func shutdown(maps []*Map) MultiError {
+	for _, m := range maps {
+		m.loopLock.Lock()
+		defer m.loopLock.Unlock()
+	}
+	for _, m := range maps {
+		m.stopMainLoop()
+	}
+	drain(maps)
+
+	results := make(chan error, len(maps))
+	for _, m := range maps {
+		go func(m *Map) {
+			results <- m.Save()
+		}(m)
+	}
+	var errs MultiError
+	for range maps {
+		if err := <-results; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// runClock broadcasts clock events to all maps until world is stopped
+func (world *World) runClock(stopped <-chan struct{}) {
+	ticker := time.NewTicker(clockTickInterval)
+	defer ticker.Stop()
+
+	last := world.clock.Elapsed()
+	for {
+		select {
+		case <-stopped:
+			return
+		case <-ticker.C:
+		}
+		now := world.clock.Elapsed()
+		for _, event := range world.clock.events(last, now) {
+			if err := world.Broadcast(WorldSender, event); err != nil {
+				fmt.Printf("Clock event %v not delivered to all maps: %v\n", event.Kind, err.Error())
+			}
+		}
+		last = now
+	}
+}
+
+// TransferEntity moves entity with all its components and descendants to position on
+// another map. Entities are removed from source map between its ticks and sent as
+// EntityTransfer message, which adds them to target map between its ticks, so they're never
+// updated by both maps. MapItem of entity is set to new position and MapItems of
+// descendants are moved by the same offset. Entity is detached from its parent,
+// relations between transferred entities are kept and other relations are removed. If target
+// inbox is full entities stay on source map.
+func (world *World) TransferEntity(entity *ecs.Entity, mapID uint8, position coord.Position) error {
+	target := world.Map(mapID)
+	if target == nil {
+		return fmt.Errorf("map %v doesn't exist", mapID)
+	}
+	if err := target.dimensions.Validate(position); err != nil {
+		return err
+	}
+	source := world.mapOf(entity)
+	if source == nil {
+		return fmt.Errorf("entity %v doesn't belong to any map", entity.ID())
+	}
+
+	source.RunBetweenTicks(func() {
+		if entity.Manager() != source.manager {
+			fmt.Printf("Entity %v left map %v before transfer\n", entity.ID(), source.id)
+			return
+		}
+		parent := entity.Parent()
+		entity.SetParent(nil)
+
+		extracted := source.extractTree(entity)
+		offset := coord.Position{}
+		if item, ok := findMapItem(extracted.Components); ok {
+			offset = position.Sub(item.Position)
+		}
+		transfer := extracted.moved(mapID, offset)
+		transfer.Components = setMapItem(transfer.Components, mapID, position)
+		if target == source {
+			source.insertTree(transfer)
+			return
+		}
+		if err := source.Post(mapID, transfer); err != nil {
+			fmt.Printf("Failed to transfer entity %v to map %v: %v\n", entity.ID(), mapID, err.Error())
+			source.insertTree(extracted)
+			entity.SetParent(parent)
+		}
+	})
+	return nil
+}
+
+// receiveEntity adds entities transferred from another map
+func (m *Map) receiveEntity(message Message) {
+	m.insertTree(message.Payload.(EntityTransfer))
+}
+
+// extractTree extracts entity and its descendants, hierarchy links and relations between
+// extracted entities are kept
+func (m *Map) extractTree(entity *ecs.Entity) EntityTransfer {
+	tree := make(map[*ecs.Entity]bool)
+	var relations []ecs.Relation
+	collectTree(entity, tree)
+	// relations are collected before extraction, which removes them
+	for e := range tree {
+		for _, r := range m.manager.AllRelations(e) {
+			if tree[r.Target] {
+				relations = append(relations, r)
+			}
+		}
+	}
+	transfer := m.extractEntities(entity)
+	transfer.Relations = relations
+	return transfer
+}
+
+func collectTree(entity *ecs.Entity, tree map[*ecs.Entity]bool) {
+	tree[entity] = true
+	for _, child := range entity.Children() {
+		collectTree(child, tree)
+	}
+}
+
+func (m *Map) extractEntities(entity *ecs.Entity) EntityTransfer {
+	transfer := EntityTransfer{Entity: entity, Components: m.manager.ExtractEntity(entity)}
+	for _, child := range entity.Children() {
+		transfer.Children = append(transfer.Children, m.extractEntities(child))
+	}
+	return transfer
+}
+
+// insertTree inserts transferred entity and its descendants, parents are inserted before
+// children. Relations are added when all entities are inserted.
+func (m *Map) insertTree(transfer EntityTransfer) {
+	m.insertEntities(transfer)
+	for _, r := range transfer.Relations {
+		if err := m.manager.AddRelation(r.Kind, r.Source, r.Target, r.Data); err != nil {
+			fmt.Printf("Failed to add relation on map %v: %v\n", m.id, err.Error())
+		}
+	}
+}
+
+func (m *Map) insertEntities(transfer EntityTransfer) {
+	if err := m.manager.InsertEntity(transfer.Entity, transfer.Components); err != nil {
+		fmt.Printf("Failed to insert entity on map %v: %v\n", m.id, err.Error())
+	}
+	for _, child := range transfer.Children {
+		m.insertEntities(child)
+	}
+}
+
+// moved returns copy of transfer with MapItems moved by offset to another map
+func (transfer EntityTransfer) moved(mapID uint8, offset coord.Position) EntityTransfer {
+	result := EntityTransfer{Entity: transfer.Entity, Components: transfer.Components, Relations: transfer.Relations}
+	if item, ok := findMapItem(transfer.Components); ok {
+		result.Components = setMapItem(transfer.Components, mapID, item.Position.Add(offset))
+	}
+	for _, child := range transfer.Children {
+		result.Children = append(result.Children, child.moved(mapID, offset))
+	}
+	return result
+}
+
+func findMapItem(components []interface{}) (component.MapItem, bool) {
+	for _, c := range components {
+		if item, ok := c.(component.MapItem); ok {
+			return item, true
+		}
+	}
+	return component.MapItem{}, false
+}
+
+// setMapItem returns copy of component values with MapItem replaced, adding it if missing
+func setMapItem(components []interface{}, mapID uint8, position coord.Position) []interface{} {
+	item := component.MapItem{Position: position, MapID: mapID}
+	result := make([]interface{}, 0, len(components)+1)
+	for _, c := range components {
+		if reflect.TypeOf(c) != component.Type.MapItem {
+			result = append(result, c)
+		}
+	}
+	return append(result, item)
 }
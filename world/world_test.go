@@ -0,0 +1,112 @@
+package world
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Tomislaw/far-worlds/component"
+	"github.com/Tomislaw/far-worlds/ecs"
+	"github.com/Tomislaw/far-worlds/world/coord"
+)
+
+func addItem(m *Map, guid string, position coord.Position) *ecs.Entity {
+	return ecs.NewEntity(m.Manager()).
+		AddComponent(component.NewGUID(guid)).
+		AddComponent(component.MapItem{MapID: m.ID(), Position: position}).
+		Register()
+}
+
+func TestGUIDUniqueInWorld(t *testing.T) {
+	w := NewWorld(Config{})
+	m1, _ := w.CreateMap(1, 2, 2)
+	m2, _ := w.CreateMap(2, 2, 2)
+	e := addItem(m1, "unique", coord.Position{X: 1, Y: 1, Z: 1})
+	m1.Update(0)
+	duplicate := addItem(m2, "unique", coord.Position{X: 1, Y: 1, Z: 1})
+	m2.Update(0)
+
+	if ecs.Has[component.GUID](duplicate) {
+		t.Fatal("expected GUID used on another map to be rejected")
+	}
+	if m, found, ok := w.FindEntity("unique"); !ok || m != m1 || found != e {
+		t.Fatalf("expected entity on map 1, got %v on %v", found, m)
+	}
+}
+
+func TestFindEntityInTransit(t *testing.T) {
+	w := NewWorld(Config{})
+	m1, _ := w.CreateMap(1, 2, 2)
+	m2, _ := w.CreateMap(2, 2, 2)
+	e := addItem(m1, "traveller", coord.Position{X: 1, Y: 1, Z: 1})
+	m1.Update(0)
+
+	if err := w.TransferEntity(e, 2, coord.Position{X: 2, Y: 2, Z: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// entity is extracted and waits in inbox of map 2
+	m1.Update(0)
+	if _, found, ok := w.FindEntity("traveller"); !ok || found != e {
+		t.Fatal("expected entity in transit to be found")
+	}
+	squatter := addItem(m1, "traveller", coord.Position{X: 1, Y: 1, Z: 1})
+	m1.Update(0)
+	if ecs.Has[component.GUID](squatter) {
+		t.Fatal("expected GUID of entity in transit to stay reserved")
+	}
+
+	m2.Update(0)
+	if m, found, ok := w.FindEntity("traveller"); !ok || m != m2 || found != e {
+		t.Fatalf("expected entity on map 2, got %v on %v", found, m)
+	}
+}
+
+func TestClockEventEmitted(t *testing.T) {
+	w := NewWorld(Config{})
+	m, _ := w.CreateMap(1, 2, 2)
+	reader := m.Manager().EventReader(reflect.TypeOf(ClockEvent{}))
+
+	if err := w.Broadcast(WorldSender, ClockEvent{Kind: Dawn}); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(0)
+
+	events := reader.Read()
+	if len(events) != 1 || events[0].(ClockEvent).Kind != Dawn {
+		t.Fatalf("expected dawn event, got %v", events)
+	}
+	if unhandled := w.bus.Metrics(1).Unhandled; unhandled != 0 {
+		t.Fatalf("expected clock event to be handled, %v messages unhandled", unhandled)
+	}
+}
+
+func TestTransferKeepsRelations(t *testing.T) {
+	w := NewWorld(Config{})
+	m1, _ := w.CreateMap(1, 2, 2)
+	m2, _ := w.CreateMap(2, 2, 2)
+	owner := addItem(m1, "owner", coord.Position{X: 1, Y: 1, Z: 1})
+	item := ecs.NewEntity(m1.Manager()).AddComponent(component.NewGUID("item")).Register()
+	other := addItem(m1, "other", coord.Position{X: 2, Y: 1, Z: 1})
+	m1.Update(0)
+	if err := owner.AppendChild(item); err != nil {
+		t.Fatal(err)
+	}
+	if err := m1.Manager().AddRelation(component.OwnedBy, item, owner, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m1.Manager().AddRelation(component.Targets, owner, other, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.TransferEntity(owner, 2, coord.Position{X: 2, Y: 2, Z: 1}); err != nil {
+		t.Fatal(err)
+	}
+	m1.Update(0)
+	m2.Update(0)
+
+	if !m2.Manager().HasRelation(component.OwnedBy, item, owner) {
+		t.Fatal("expected relation inside transferred tree to be kept")
+	}
+	if m2.Manager().HasRelation(component.Targets, owner, other) || m1.Manager().HasRelation(component.Targets, owner, other) {
+		t.Fatal("expected relation to entity left on source map to be removed")
+	}
+}